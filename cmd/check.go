@@ -1,25 +1,51 @@
 package cmd
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"s3-check/internal/checker"
+	"s3-check/internal/input"
+	"s3-check/internal/s3client"
 )
 
 const (
-	colorReset  = "\033[0m"
-	colorRed    = "\033[31m"
-	colorGreen  = "\033[32m"
+	// How long cleanup of test objects may take after the scan ends or is interrupted
+	cleanupTimeout = 30 * time.Second
+
+	// Width of the RISK column, fits CRITICAL
+	riskWidth = 8
+	// How many of the riskiest buckets the summary lists
+	topOffenders = 5
 )
 
 var (
 	fromFile  string
 	fromStdin bool
 	verbose   bool
+	transport string
+	concurrency int
+	ordered   bool
+	rps       float64
+	bucketRPS float64
+	maxRetries int
+	opTimeout time.Duration
+	bucketTimeout time.Duration
+	outputFormat string
+	outputDetails bool
+	failOnFlag    string
+	colorMode     string
+	legacyNames   bool
+	accountID     string
+	configChecks  bool
 	maxBucketWidth int
 )
 
@@ -27,7 +53,7 @@ var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check permissions for specific buckets",
 	Long: `Check permissions for S3 buckets. Buckets can be specified in multiple ways:
-1. As command line arguments: ./s3-check check bucket1 bucket2
+1. As command line arguments: ./s3-check check bucket1 s3://bucket2/prefix
 2. From a file: ./s3-check check --file buckets.txt
 3. From stdin: echo "bucket1" | ./s3-check check --stdin
 4. All buckets: ./s3-check check (requires AWS permissions to list all buckets)`,
@@ -37,52 +63,134 @@ var checkCmd = &cobra.Command{
 func init() {
 	checkCmd.Flags().StringVarP(&fromFile, "file", "f", "", "Read bucket names from file (one per line)")
 	checkCmd.Flags().BoolVarP(&fromStdin, "stdin", "i", false, "Read bucket names from stdin (one per line)")
-	checkCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed error messages for debugging")
+	checkCmd.Flags().BoolVar(&legacyNames, "legacy-names", false, "Check names only valid under the legacy us-east-1 rules (uppercase, underscores, up to 255 characters)")
+	addScanFlags(checkCmd)
+}
+
+// addScanFlags registers the flags of every command that runs the permission checks
+func addScanFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed error messages for debugging")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", checker.DefaultConcurrency, "Number of buckets to check at the same time")
+	cmd.Flags().BoolVar(&ordered, "ordered", false, "Print results in input order instead of as they complete")
+	cmd.Flags().Float64Var(&rps, "rps", checker.DefaultRPS, "Maximum requests per second across all buckets (0 = unlimited)")
+	cmd.Flags().Float64Var(&bucketRPS, "bucket-rps", 0, "Maximum requests per second to a single bucket (0 = unlimited)")
+	cmd.Flags().IntVar(&maxRetries, "max-retries", checker.DefaultMaxRetries, "Retries for throttled requests (SlowDown, 503, RequestTimeout)")
+	cmd.Flags().DurationVar(&opTimeout, "timeout", checker.DefaultOpTimeout, "Timeout for each S3 request (0 = none)")
+	cmd.Flags().DurationVar(&bucketTimeout, "bucket-timeout", 5*time.Minute, "Timeout for all checks of one bucket (0 = none)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table, json, jsonl, csv, tsv, sarif or html")
+	cmd.Flags().BoolVar(&outputDetails, "details", false, "Add existence and per-check error code columns to csv/tsv output")
+	cmd.Flags().StringVar(&failOnFlag, "fail-on", "none", "Exit with code 1 on findings at or above a severity (critical, high, medium, low) or on OK in a list of columns (e.g. ANON-WRITE,PUT-ACL), and with code 2 on checks that errored; none exits 0 for any completed scan")
+	cmd.Flags().StringVar(&colorMode, "color", "auto", "Color the table: auto (only on a terminal without NO_COLOR), always or never")
+	cmd.Flags().StringVar(&transport, "transport", "http", "How to reach S3: http (native client) or cli (aws s3api)")
+	cmd.Flags().StringVar(&accountID, "account-id", "", "Your AWS account ID, for account-level Block Public Access and to leave it out of policy grants (default: looked up with STS)")
+	cmd.Flags().BoolVar(&configChecks, "config", false, "Also read the CORS, website, logging, versioning, encryption, lifecycle and tagging configuration")
 }
 
 func runCheck(cmd *cobra.Command, args []string) error {
-	var buckets []string
+	var targets []input.Target
+	var listedAll bool
 	var err error
 
+	// From here on errors are about the scan, not how the command was called
+	cmd.SilenceUsage = true
+
+	writer, threshold, err := prepareOutput()
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C cancels in-flight checks; results gathered so far are kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Check if stdin is a pipe (piped input)
 	isStdinPipe := isStdinPipe()
 
 	// Priority: explicit stdin flag > file > args > piped stdin > list all buckets
 	if fromStdin {
-		buckets, err = readFromStdin()
+		targets, err = readFromStdin()
 		if err != nil {
-			return fmt.Errorf("error reading from stdin: %w", err)
+			return withExitCode(ExitBadInput, fmt.Errorf("error reading from stdin: %w", err))
 		}
 	} else if fromFile != "" {
-		buckets, err = readFromFile(fromFile)
+		targets, err = readFromFile(fromFile)
 		if err != nil {
-			return fmt.Errorf("error reading from file: %w", err)
+			return withExitCode(ExitBadInput, fmt.Errorf("error reading from file: %w", err))
 		}
 	} else if len(args) > 0 {
-		buckets = args
+		var problems []*input.LineError
+		targets, problems = input.Normalize(args)
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "warning: skipping argument %d: %q: %v\n", problem.Line, problem.Text, problem.Err)
+		}
 	} else if isStdinPipe {
 		// If stdin is piped and no other input specified, read from stdin
-		buckets, err = readFromStdin()
+		targets, err = readFromStdin()
 		if err != nil {
-			return fmt.Errorf("error reading from stdin: %w", err)
+			return withExitCode(ExitBadInput, fmt.Errorf("error reading from stdin: %w", err))
 		}
-		if len(buckets) == 0 {
-			return fmt.Errorf("no buckets provided via stdin")
+		if len(targets) == 0 {
+			return withExitCode(ExitBadInput, fmt.Errorf("no buckets provided via stdin"))
 		}
 	} else {
 		// No input specified and stdin is not a pipe - list all buckets
-		checker, err := checker.NewChecker()
+		c, err := newChecker()
 		if err != nil {
-			return fmt.Errorf("error initializing checker: %w", err)
+			return withExitCode(ExitBadInput, fmt.Errorf("error initializing checker: %w", err))
 		}
-		buckets, err = checker.ListAllBuckets()
+		names, err := c.ListAllBuckets(ctx)
 		if err != nil {
-			return fmt.Errorf("error listing buckets: %w", err)
+			return withExitCode(ExitScanError, fmt.Errorf("error listing buckets: %w", err))
+		}
+		for _, name := range names {
+			targets = append(targets, input.Target{Bucket: name})
 		}
+		listedAll = true
 	}
 
-	if len(buckets) == 0 {
-		return fmt.Errorf("no buckets to check")
+	// Names we were given may be mistyped or repeated; names S3 listed are not
+	if !listedAll {
+		targets = validateTargets(targets)
+	}
+	if len(targets) == 0 {
+		return withExitCode(ExitBadInput, fmt.Errorf("no buckets to check"))
+	}
+
+	c, err := newChecker()
+	if err != nil {
+		return withExitCode(ExitBadInput, fmt.Errorf("error initializing checker: %w", err))
+	}
+	return scan(ctx, stop, c, targets, writer, threshold)
+}
+
+// prepareOutput builds the result writer and --fail-on threshold from the flags
+func prepareOutput() (resultWriter, failOn, error) {
+	tableStyle, err := styleFor(colorMode, os.Stdout)
+	if err != nil {
+		return nil, failOn{}, withExitCode(ExitBadInput, err)
+	}
+	writer, err := newResultWriter(outputFormat, os.Stdout, tableStyle)
+	if err != nil {
+		return nil, failOn{}, withExitCode(ExitBadInput, err)
+	}
+	// Configuration columns go in before --fail-on can name them
+	if configChecks {
+		resultColumns = append(resultColumns, configColumns...)
+	}
+	threshold, err := parseFailOn(failOnFlag)
+	if err != nil {
+		return nil, failOn{}, withExitCode(ExitBadInput, err)
+	}
+	return writer, threshold, nil
+}
+
+// scan checks every target, streams the results to writer and cleans up
+// test objects afterwards. stop releases the Ctrl-C handler of ctx. The
+// returned error carries the process exit code.
+func scan(ctx context.Context, stop context.CancelFunc, c *checker.Checker, targets []input.Target, writer resultWriter, threshold failOn) error {
+	buckets := make([]string, len(targets))
+	for i, target := range targets {
+		buckets[i] = target.Bucket
 	}
 
 	// Calculate max bucket name width for dynamic column sizing
@@ -92,56 +200,185 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		maxBucketWidth = len("BUCKET")
 	}
 
-	checker, err := checker.NewChecker()
-	if err != nil {
-		return fmt.Errorf("error initializing checker: %w", err)
+	// Regions named by endpoint URLs save a lookup; a wrong one is corrected by the first redirect
+	for _, target := range targets {
+		if target.Region != "" {
+			c.SetRegionHint(target.Bucket, target.Region)
+		}
 	}
 
-	// Set verbose mode if requested
-	checker.SetVerbose(verbose)
-
 	// Print header once
-	printHeader()
+	if err := writer.Begin(); err != nil {
+		return withExitCode(ExitScanError, err)
+	}
 
-	// Stream results as they come in
-	err = checker.CheckBucketsStream(buckets, printResult)
-	if err != nil {
-		return fmt.Errorf("error checking buckets: %w", err)
+	// Stream results as they come in, tallying what decides the exit code
+	var writeErr error
+	var failed, errored int
+	err := c.CheckBucketsStream(ctx, buckets, func(result checker.BucketResult) {
+		if werr := writer.Write(result); werr != nil && writeErr == nil {
+			writeErr = werr
+		}
+		if threshold.matches(result) {
+			failed++
+		}
+		if hasErrors(result) {
+			errored++
+		}
+	})
+	interrupted := ctx.Err() != nil
+	// Restore default signal handling so a second Ctrl-C exits immediately
+	stop()
+	if interrupted {
+		fmt.Fprintln(os.Stderr, "\nInterrupted, cleaning up test objects...")
+	}
+
+	// Delete any test objects the checks could not remove themselves
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	if cleanupErr := c.Cleanup(cleanupCtx); cleanupErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", cleanupErr)
 	}
 
-	// Print legend at the end
-	printLegend()
+	// Print legend (or close the JSON document) at the end, with whatever was gathered
+	if err := writer.End(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		return withExitCode(ExitScanError, fmt.Errorf("error writing results: %w", writeErr))
+	}
+
+	if interrupted {
+		return withExitCode(ExitScanError, fmt.Errorf("interrupted, results are incomplete"))
+	}
+	if err != nil {
+		return withExitCode(ExitScanError, fmt.Errorf("error checking buckets: %w", err))
+	}
+	// A confirmed finding outranks checks that could not complete elsewhere
+	if failed > 0 {
+		return withExitCode(ExitFindings, fmt.Errorf("%d of %d buckets have findings at or above --fail-on %s", failed, len(buckets), failOnFlag))
+	}
+	if errored > 0 && threshold.enabled() {
+		return withExitCode(ExitScanError, fmt.Errorf("%d of %d buckets have checks that could not complete", errored, len(buckets)))
+	}
 	return nil
 }
 
-func readFromStdin() ([]string, error) {
-	var buckets []string
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" {
-			buckets = append(buckets, line)
+// newChecker builds a checker using the transport selected with --transport
+// and configured from the scan flags
+func newChecker() (*checker.Checker, error) {
+	var opts []checker.Option
+	switch transport {
+	case "http", "":
+	case "cli":
+		opts = append(opts, checker.WithTransport(s3client.NewCLI()))
+	default:
+		return nil, fmt.Errorf("unknown transport %q (want http or cli)", transport)
+	}
+	if accountID != "" && !isAccountID(accountID) {
+		return nil, fmt.Errorf("invalid --account-id %q (want 12 digits)", accountID)
+	}
+	c, err := checker.NewChecker(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetVerbose(verbose)
+	c.SetConcurrency(concurrency)
+	c.SetOrdered(ordered)
+	c.SetRateLimit(rps, bucketRPS)
+	c.SetMaxRetries(maxRetries)
+	c.SetTimeouts(opTimeout, bucketTimeout)
+	c.SetAccountID(accountID)
+	c.SetConfigChecks(configChecks)
+	return c, nil
+}
+
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
 		}
 	}
-	return buckets, scanner.Err()
+	return true
 }
 
-func readFromFile(filename string) ([]string, error) {
+func readFromStdin() ([]input.Target, error) {
+	return readTargets(os.Stdin, "stdin")
+}
+
+func readFromFile(filename string) ([]input.Target, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	var buckets []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" {
-			buckets = append(buckets, line)
+	return readTargets(file, filename)
+}
+
+// readTargets reads one bucket reference per line (a name, s3:// URI, ARN or
+// S3 URL), warning about lines that do not name a bucket
+func readTargets(r io.Reader, source string) ([]input.Target, error) {
+	targets, problems, err := input.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "warning: skipping %s %v\n", source, problem)
+	}
+	return targets, nil
+}
+
+// validateTargets drops bucket names S3 would reject and repeated names
+// (in any case), warning about each one on stderr and summarising the count.
+// A repeat still lends its region hint to the first mention.
+// Names only valid under the legacy us-east-1 rules are kept with
+// --legacy-names, unless the input placed them in another region.
+func validateTargets(targets []input.Target) []input.Target {
+	var kept []input.Target
+	var invalid, duplicates int
+	seen := make(map[string]int)
+
+	for _, target := range targets {
+		kind, err := input.ValidateName(target.Bucket)
+		switch {
+		case kind == "":
+			fmt.Fprintf(os.Stderr, "warning: skipping %q: invalid bucket name: %v\n", target.Bucket, err)
+			invalid++
+			continue
+		case kind == input.NameLegacy && !legacyNames:
+			fmt.Fprintf(os.Stderr, "warning: skipping %q: invalid bucket name: %v (use --legacy-names to check it as a legacy us-east-1 bucket)\n", target.Bucket, err)
+			invalid++
+			continue
+		case kind == input.NameLegacy && target.Region != "" && target.Region != "us-east-1":
+			fmt.Fprintf(os.Stderr, "warning: skipping %q: invalid bucket name: %v (legacy names only exist in us-east-1, not %s)\n", target.Bucket, err, target.Region)
+			invalid++
+			continue
+		case kind == input.NameLegacy:
+			fmt.Fprintf(os.Stderr, "warning: %q is only valid as a legacy us-east-1 bucket name: %v\n", target.Bucket, err)
 		}
+
+		folded := strings.ToLower(target.Bucket)
+		if idx, ok := seen[folded]; ok {
+			fmt.Fprintf(os.Stderr, "warning: skipping %q: duplicate of %q\n", target.Bucket, kept[idx].Bucket)
+			if kept[idx].Region == "" {
+				kept[idx].Region = target.Region
+			}
+			duplicates++
+			continue
+		}
+		seen[folded] = len(kept)
+		kept = append(kept, target)
+	}
+
+	if dropped := invalid + duplicates; dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Dropped %d of %d bucket names (%d invalid, %d duplicate)\n", dropped, len(targets), invalid, duplicates)
 	}
-	return buckets, scanner.Err()
+	return kept
 }
 
 // isStdinPipe checks if stdin is a pipe or redirected input (not a terminal)
@@ -166,46 +403,133 @@ func calculateMaxBucketWidth(buckets []string) int {
 	return maxWidth
 }
 
+// column is one permission column of the results table
+type column struct {
+	header string
+	width  int
+	check  func(checker.BucketResult) checker.Check
+	// showCount appends the number of items observed to OK cells, e.g. "OK (12)"
+	showCount bool
+	// okText replaces the text of OK cells, e.g. with the setting that was read
+	okText func(checker.BucketResult) string
+}
+
+// ownershipText abbreviates the Object Ownership modes to fit the column
+var ownershipText = map[checker.ObjectOwnership]string{
+	checker.OwnershipEnforced:     "ENFORCED",
+	checker.OwnershipPreferred:    "PREFERRED",
+	checker.OwnershipObjectWriter: "WRITER",
+}
+
+var resultColumns = []column{
+	{header: "HEAD", width: 9, check: func(r checker.BucketResult) checker.Check { return r.HeadBucket }},
+	{header: "PAB", width: 9, check: func(r checker.BucketResult) checker.Check { return r.PublicAccessBlock }, showCount: true},
+	{header: "OWNERSHIP", width: 9, check: func(r checker.BucketResult) checker.Check { return r.Ownership }, okText: func(r checker.BucketResult) string { return ownershipText[r.ObjectOwnership] }},
+	{header: "POLICY", width: 9, check: func(r checker.BucketResult) checker.Check { return r.Policy }, showCount: true},
+	{header: "GET-ACL", width: 9, check: func(r checker.BucketResult) checker.Check { return r.GetACL }, showCount: true},
+	{header: "PUT-ACL", width: 9, check: func(r checker.BucketResult) checker.Check { return r.PutACL }},
+	{header: "ANON-GET", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AnonGet }},
+	{header: "AUTH-GET", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AuthGet }},
+	{header: "ANON-LIST", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AnonList }, showCount: true},
+	{header: "AUTH-LIST", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AuthList }, showCount: true},
+	{header: "ANON-VERS", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AnonListVersions }, showCount: true},
+	{header: "AUTH-VERS", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AuthListVersions }, showCount: true},
+	{header: "ANON-WRITE", width: 10, check: func(r checker.BucketResult) checker.Check { return r.AnonWrite }},
+	{header: "AUTH-WRITE", width: 10, check: func(r checker.BucketResult) checker.Check { return r.AuthWrite }},
+	{header: "ANON-DEL", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AnonDel }},
+	{header: "AUTH-DEL", width: 9, check: func(r checker.BucketResult) checker.Check { return r.AuthDel }},
+}
+
+// configColumns are the configuration read checks, added to resultColumns with --config
+var configColumns = []column{
+	{header: "CORS", width: 9, check: configCheck(func(c *checker.BucketConfig) checker.Check { return c.CORS.Check }), showCount: true},
+	{header: "WEBSITE", width: 9, check: configCheck(func(c *checker.BucketConfig) checker.Check { return c.Website.Check }), okText: func(r checker.BucketResult) string { return onOff(r.Config.Website.Enabled) }},
+	{header: "LOGGING", width: 9, check: configCheck(func(c *checker.BucketConfig) checker.Check { return c.Logging.Check }), okText: func(r checker.BucketResult) string { return onOff(r.Config.Logging.Enabled) }},
+	{header: "VERSIONING", width: 10, check: configCheck(func(c *checker.BucketConfig) checker.Check { return c.Versioning.Check }), okText: func(r checker.BucketResult) string { return versioningText(r.Config.Versioning) }},
+	{header: "ENCRYPTION", width: 10, check: configCheck(func(c *checker.BucketConfig) checker.Check { return c.Encryption.Check }), okText: func(r checker.BucketResult) string { return encryptionText(r.Config.Encryption) }},
+	{header: "LIFECYCLE", width: 9, check: configCheck(func(c *checker.BucketConfig) checker.Check { return c.Lifecycle.Check }), showCount: true},
+	{header: "TAGGING", width: 9, check: configCheck(func(c *checker.BucketConfig) checker.Check { return c.Tagging.Check }), showCount: true},
+}
+
+// configCheck reads a configuration check of a result; results without
+// configuration checks show it as skipped
+func configCheck(get func(*checker.BucketConfig) checker.Check) func(checker.BucketResult) checker.Check {
+	return func(r checker.BucketResult) checker.Check {
+		if r.Config == nil {
+			return checker.Check{Status: checker.StatusSkipped}
+		}
+		return get(r.Config)
+	}
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "ON"
+	}
+	return "OFF"
+}
+
+func versioningText(v checker.VersioningConfig) string {
+	if v.State == "" {
+		return "OFF"
+	}
+	return strings.ToUpper(string(v.State))
+}
+
+// encryptionText names the default encryption the way the S3 console does
+func encryptionText(e checker.EncryptionConfig) string {
+	switch e.Algorithm {
+	case "":
+		return "OFF"
+	case "AES256":
+		return "SSE-S3"
+	case "aws:kms":
+		return "SSE-KMS"
+	case "aws:kms:dsse":
+		return "DSSE-KMS"
+	}
+	return e.Algorithm
+}
+
+// cellText is the visible text of a column's cell
+func (col column) cellText(result checker.BucketResult) string {
+	check := col.check(result)
+	if col.okText != nil && check.Status == checker.StatusOK {
+		if text := col.okText(result); text != "" {
+			return text
+		}
+	}
+	if col.showCount && check.Status == checker.StatusOK {
+		return fmt.Sprintf("%s (%d)", check.Status, check.Count)
+	}
+	return string(check.Status)
+}
+
 func printHeader() {
 	fmt.Println()
 	// Use dynamic width for BUCKET column
-	fmt.Printf("%-*s | %-8s | %-8s | %-9s | %-9s | %-10s | %-10s | %-9s | %-9s\n",
-		maxBucketWidth, "BUCKET", "GET-ACL", "PUT-ACL", "ANON-GET", "AUTH-GET", "ANON-WRITE", "AUTH-WRITE", "ANON-DEL", "AUTH-DEL")
+	header := fmt.Sprintf("%-*s | %-14s | %-*s", maxBucketWidth, "BUCKET", "REGION", riskWidth, "RISK")
 	// Create separator with dynamic width
-	separator := strings.Repeat("-", maxBucketWidth) + "-+-" + 
-		strings.Repeat("-", 8) + "-+-" + strings.Repeat("-", 8) + "-+-" +
-		strings.Repeat("-", 9) + "-+-" + strings.Repeat("-", 9) + "-+-" + 
-		strings.Repeat("-", 10) + "-+-" + strings.Repeat("-", 10) + "-+-" + 
-		strings.Repeat("-", 9) + "-+-" + strings.Repeat("-", 9)
+	separator := strings.Repeat("-", maxBucketWidth) + "-+-" + strings.Repeat("-", 14) + "-+-" + strings.Repeat("-", riskWidth)
+	for _, col := range resultColumns {
+		header += fmt.Sprintf(" | %-*s", col.width, col.header)
+		separator += "-+-" + strings.Repeat("-", col.width)
+	}
+	fmt.Println(header)
 	fmt.Println(separator)
 }
 
-func printResult(result checker.BucketResult) {
+func printResult(result checker.BucketResult, st style) {
 	// Use dynamic width for bucket name column
-	fmt.Printf("%-*s | %s | %s | %s | %s | %s | %s | %s | %s\n",
-		maxBucketWidth, result.BucketName,
-		colorizeStatus(result.GetACL, 8),
-		colorizeStatus(result.PutACL, 8),
-		colorizeStatus(result.AnonGet, 9),
-		colorizeStatus(result.AuthGet, 9),
-		colorizeStatus(result.AnonWrite, 10),
-		colorizeStatus(result.AuthWrite, 10),
-		colorizeStatus(result.AnonDel, 9),
-		colorizeStatus(result.AuthDel, 9))
-}
-
-func colorizeStatus(status string, width int) string {
-	color := colorRed
-	if status == "OK" {
-		color = colorGreen
+	region := result.Region
+	if region == "" {
+		region = "-"
 	}
-	// Pad the status to the specified width
-	// ANSI codes are invisible, so we need to pad based on visible length
-	padding := width - len(status)
-	if padding > 0 {
-		return fmt.Sprintf("%s%s%s%s", color, status, strings.Repeat(" ", padding), colorReset)
+	line := fmt.Sprintf("%-*s | %-14s | %s", maxBucketWidth, result.BucketName, region, st.risk(result.Risk, pad(string(result.Risk), riskWidth)))
+	for _, col := range resultColumns {
+		line += " | " + st.status(col.check(result).Status, pad(col.cellText(result), col.width))
 	}
-	return fmt.Sprintf("%s%s%s", color, status, colorReset)
+	fmt.Println(line)
 }
 
 func printLegend() {
@@ -213,6 +537,71 @@ func printLegend() {
 	fmt.Println("Legend:")
 	fmt.Println("  ANON - Anonymous (unauthenticated) access")
 	fmt.Println("  AUTH - Authenticated access")
+	fmt.Println("  PAB - Block Public Access; (n) = flags in effect at bucket or account level")
+	fmt.Println("  OWNERSHIP - Object Ownership: ENFORCED (ACLs disabled), PREFERRED or WRITER")
+	fmt.Println("  POLICY - GetBucketPolicy, GET-ACL - GetBucketAcl; (n) = grants to other accounts or the public")
+	fmt.Println("  LIST - ListObjectsV2, VERS - ListObjectVersions; (n) = keys on the first page")
+	fmt.Println("  RISK - CRITICAL: ANON-WRITE/ANON-DEL/public ACL write, HIGH: PUT-ACL/cross-account ACL write,")
+	fmt.Println("         MEDIUM: ANON-GET/ANON-LIST/public ACL read, LOW: LogDelivery ACL grant")
+	if configChecks {
+		fmt.Println("  CORS, LIFECYCLE, TAGGING - (n) = rules or tags configured; WEBSITE, LOGGING - ON or OFF")
+		fmt.Println("  VERSIONING - ENABLED, SUSPENDED or OFF; ENCRYPTION - default encryption (SSE-S3, SSE-KMS, DSSE-KMS) or OFF")
+	}
+	fmt.Println()
+	fmt.Println("  OK        - Operation allowed")
+	fmt.Println("  DENIED    - Operation refused by S3 (e.g. AccessDenied)")
+	fmt.Println("  NOT_FOUND - Bucket does not exist")
+	fmt.Println("  ERROR     - Check could not complete (network, credentials, throttling)")
+	fmt.Println("  SKIPPED   - Check not attempted (e.g. the bucket does not exist)")
+	fmt.Println("  UNKNOWN   - S3 answered but the result is inconclusive (e.g. redirect)")
+	fmt.Println("  N/A       - Check does not apply (e.g. ACL checks when ACLs are disabled)")
 	fmt.Println()
 }
 
+// printRiskSummary counts buckets per severity and lists the riskiest ones
+func printRiskSummary(results []checker.BucketResult, st style) {
+	counts := make(map[checker.Severity]int)
+	var offenders []checker.BucketResult
+	for _, result := range results {
+		if len(result.Findings) == 0 {
+			continue
+		}
+		counts[result.Risk]++
+		offenders = append(offenders, result)
+	}
+
+	fmt.Println("Risk summary:")
+	fmt.Printf("  %d of %d buckets have findings\n", len(offenders), len(results))
+	for _, severity := range checker.Severities {
+		fmt.Printf("  %s %d\n", st.risk(severity, pad(string(severity), riskWidth)), counts[severity])
+	}
+	if len(offenders) == 0 {
+		fmt.Println()
+		return
+	}
+
+	sort.SliceStable(offenders, func(i, j int) bool {
+		a, b := offenders[i], offenders[j]
+		if a.Risk.Rank() != b.Risk.Rank() {
+			return a.Risk.Rank() > b.Risk.Rank()
+		}
+		if len(a.Findings) != len(b.Findings) {
+			return len(a.Findings) > len(b.Findings)
+		}
+		return a.BucketName < b.BucketName
+	})
+	if len(offenders) > topOffenders {
+		offenders = offenders[:topOffenders]
+	}
+
+	fmt.Println()
+	fmt.Println("Top offenders:")
+	for _, result := range offenders {
+		checks := make([]string, len(result.Findings))
+		for i, finding := range result.Findings {
+			checks[i] = finding.Check
+		}
+		fmt.Printf("  %s %s (%s)\n", st.risk(result.Risk, pad(string(result.Risk), riskWidth)), result.BucketName, strings.Join(checks, ", "))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+
+	"s3-check/internal/input"
+)
+
+func TestValidateTargets(t *testing.T) {
+	targets := []input.Target{
+		{Bucket: "my-bucket-one"},
+		{Bucket: "my-bucket-one"},
+		{Bucket: "my-bucket-one", Region: "eu-west-1", Key: "x"},
+		{Bucket: "Bad_Name"},
+		{Bucket: "MY-BUCKET-ONE"},
+		{Bucket: "my-bucket-two"},
+	}
+	kept := validateTargets(targets)
+	if len(kept) != 2 || kept[0].Bucket != "my-bucket-one" || kept[1].Bucket != "my-bucket-two" {
+		t.Fatalf("kept %+v, want my-bucket-one and my-bucket-two", kept)
+	}
+	// The region hint of a repeat carries over to the first mention
+	if kept[0].Region != "eu-west-1" {
+		t.Errorf("region of my-bucket-one = %q, want eu-west-1", kept[0].Region)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"s3-check/internal/checker"
+	"s3-check/internal/enum"
+	"s3-check/internal/input"
+)
+
+var (
+	wordlistFile string
+	noBuiltin    bool
+	dryRun       bool
+)
+
+var enumCmd = &cobra.Command{
+	Use:   "enum KEYWORD [KEYWORD...]",
+	Short: "Discover buckets from permutations of keywords and check their permissions",
+	Long: `Generate candidate bucket names from keywords such as a company or product name,
+combined with a wordlist, environments (dev, prod, ...) and regions, joined with
+"", "-" and ".". Every candidate is probed with HEAD bucket, and the buckets that
+exist are then checked like the check command does:
+  ./s3-check enum acme acme-payments
+  ./s3-check enum acme --wordlist words.txt -o jsonl
+  ./s3-check enum acme --dry-run`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEnum,
+}
+
+func init() {
+	enumCmd.Flags().StringVarP(&wordlistFile, "wordlist", "w", "", "Extra words to combine with the keywords (one per line)")
+	enumCmd.Flags().BoolVar(&noBuiltin, "no-builtin", false, "Do not use the built-in wordlist (only --wordlist)")
+	enumCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the candidate names without sending any request")
+	addScanFlags(enumCmd)
+}
+
+func runEnum(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	opts := enum.DefaultOptions()
+	if noBuiltin {
+		opts.Words = nil
+	}
+	if wordlistFile != "" {
+		file, err := os.Open(wordlistFile)
+		if err != nil {
+			return withExitCode(ExitBadInput, fmt.Errorf("error reading wordlist: %w", err))
+		}
+		words, err := enum.ReadWordlist(file)
+		file.Close()
+		if err != nil {
+			return withExitCode(ExitBadInput, fmt.Errorf("error reading wordlist: %w", err))
+		}
+		opts.Words = append(opts.Words, words...)
+	}
+
+	candidates := enum.Generate(args, opts)
+	if len(candidates) == 0 {
+		return withExitCode(ExitBadInput, fmt.Errorf("no valid bucket names can be made from %v", args))
+	}
+	if dryRun {
+		for _, name := range candidates {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	writer, threshold, err := prepareOutput()
+	if err != nil {
+		return err
+	}
+	c, err := newChecker()
+	if err != nil {
+		return withExitCode(ExitBadInput, fmt.Errorf("error initializing checker: %w", err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Probe every candidate with HEAD bucket; only buckets that exist are checked
+	fmt.Fprintf(os.Stderr, "Probing %d candidate bucket names...\n", len(candidates))
+	var hits []input.Target
+	var unprobed int
+	err = c.ProbeBuckets(ctx, candidates, func(result checker.BucketResult) {
+		switch result.Existence {
+		case checker.ExistenceExists, checker.ExistenceOwnedByOther, checker.ExistenceRedirect:
+			fmt.Fprintf(os.Stderr, "found: %s (%s)\n", result.BucketName, result.Existence)
+			hits = append(hits, input.Target{Bucket: result.BucketName, Region: result.Region})
+		case checker.ExistenceUnknown:
+			// Network or credential failures: the bucket may well exist
+			unprobed++
+			if verbose {
+				fmt.Fprintf(os.Stderr, "could not probe: %s (%s)\n", result.BucketName, result.HeadBucket.Detail)
+			}
+		}
+	})
+	if ctx.Err() != nil {
+		return withExitCode(ExitScanError, fmt.Errorf("interrupted while probing, %d buckets found so far", len(hits)))
+	}
+	if err != nil {
+		return withExitCode(ExitScanError, fmt.Errorf("error probing buckets: %w", err))
+	}
+	fmt.Fprintf(os.Stderr, "Found %d existing buckets among %d candidates\n", len(hits), len(candidates))
+	if unprobed > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d of %d candidates could not be probed (network or credential errors; -v lists them)\n", unprobed, len(candidates))
+	}
+
+	if len(hits) == 0 {
+		// Still write an (empty) document so machine readable output stays valid
+		if err := writer.Begin(); err != nil {
+			return withExitCode(ExitScanError, err)
+		}
+		if err := writer.End(); err != nil {
+			return withExitCode(ExitScanError, fmt.Errorf("error writing results: %w", err))
+		}
+		return nil
+	}
+	return scan(ctx, stop, c, hits, writer, threshold)
+}
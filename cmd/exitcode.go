@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"s3-check/internal/checker"
+)
+
+// Process exit codes of the check command
+const (
+	// ExitClean: the scan completed and nothing reached the --fail-on threshold
+	ExitClean = 0
+	// ExitFindings: at least one bucket has a finding at or above the threshold
+	ExitFindings = 1
+	// ExitScanError: the scan could not complete or some checks errored
+	ExitScanError = 2
+	// ExitBadInput: invalid flags, arguments or bucket list
+	ExitBadInput = 3
+)
+
+// exitError carries the exit code an error should end the process with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode maps an error returned by Execute to the process exit code.
+// Errors raised by cobra itself (unknown flags or commands) are bad input.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitClean
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitBadInput
+}
+
+// failOn decides which results count as findings for the exit code: either
+// every finding at or above a severity, or an OK on any of a list of columns
+type failOn struct {
+	severity checker.Severity
+	columns  []column
+}
+
+// parseFailOn reads --fail-on: "none", a severity (critical, high, medium, low)
+// or a comma separated list of table columns such as ANON-WRITE,PUT-ACL
+func parseFailOn(value string) (failOn, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "none") {
+		return failOn{}, nil
+	}
+	for _, severity := range checker.Severities {
+		if strings.EqualFold(value, string(severity)) {
+			return failOn{severity: severity}, nil
+		}
+	}
+
+	var f failOn
+	for _, name := range strings.Split(value, ",") {
+		col, ok := columnByHeader(strings.TrimSpace(name))
+		if !ok {
+			return failOn{}, fmt.Errorf("invalid --fail-on %q: want none, critical, high, medium, low or a list of columns (e.g. ANON-WRITE,PUT-ACL)", value)
+		}
+		f.columns = append(f.columns, col)
+	}
+	return f, nil
+}
+
+// enabled reports whether the run gates on results at all; with --fail-on
+// none any completed scan exits 0
+func (f failOn) enabled() bool {
+	return f.severity != "" || len(f.columns) > 0
+}
+
+// matches reports whether result should fail the run
+func (f failOn) matches(result checker.BucketResult) bool {
+	if f.severity != "" && result.Risk.Rank() >= f.severity.Rank() {
+		return true
+	}
+	for _, col := range f.columns {
+		if col.check(result).Status == checker.StatusOK {
+			return true
+		}
+	}
+	return false
+}
+
+func columnByHeader(header string) (column, bool) {
+	for _, col := range resultColumns {
+		if strings.EqualFold(col.header, header) {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// hasErrors reports whether any check of result could not complete
+func hasErrors(result checker.BucketResult) bool {
+	for _, col := range resultColumns {
+		if col.check(result).Status == checker.StatusError {
+			return true
+		}
+	}
+	return false
+}
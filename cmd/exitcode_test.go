@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"s3-check/internal/checker"
+)
+
+func TestFailOnDefaultsToNone(t *testing.T) {
+	flag := checkCmd.Flags().Lookup("fail-on")
+	if flag == nil || flag.DefValue != "none" {
+		t.Fatalf("--fail-on default = %v, want none", flag)
+	}
+	threshold, err := parseFailOn(flag.DefValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threshold.enabled() || threshold.matches(checker.BucketResult{Risk: checker.SeverityCritical}) {
+		t.Error("the default threshold gates the exit code")
+	}
+}
+
+func TestParseFailOn(t *testing.T) {
+	medium, err := parseFailOn("medium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !medium.enabled() {
+		t.Error("medium is not enabled")
+	}
+	if !medium.matches(checker.BucketResult{Risk: checker.SeverityHigh}) || medium.matches(checker.BucketResult{Risk: checker.SeverityLow}) {
+		t.Error("medium does not match exactly the results at or above MEDIUM")
+	}
+	if _, err := parseFailOn("ANON-WRITE,NOPE"); err == nil {
+		t.Error("unknown column accepted")
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"s3-check/internal/checker"
+)
+
+// resultWriter renders the stream of bucket results in one output format
+type resultWriter interface {
+	// Begin is called once before the first result
+	Begin() error
+	// Write is called for every bucket as soon as its checks complete
+	Write(result checker.BucketResult) error
+	// End is called once after the last result, also when the scan was interrupted
+	End() error
+}
+
+var outputFormats = []string{"table", "json", "jsonl", "csv", "tsv", "sarif", "html"}
+
+// newResultWriter returns the writer for format; st only applies to the table
+func newResultWriter(format string, w io.Writer, st style) (resultWriter, error) {
+	switch format {
+	case "table", "":
+		return &tableWriter{style: st}, nil
+	case "json":
+		return &jsonWriter{w: w}, nil
+	case "jsonl":
+		return &jsonLinesWriter{enc: json.NewEncoder(w)}, nil
+	case "csv":
+		return newCSVWriter(w, ',', outputDetails), nil
+	case "tsv":
+		return newCSVWriter(w, '\t', outputDetails), nil
+	case "sarif":
+		return &sarifWriter{w: w}, nil
+	case "html":
+		return &htmlWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (want one of %v)", format, outputFormats)
+}
+
+// tableWriter prints the fixed-width table in its style, followed by the legend
+// and a risk summary
+type tableWriter struct {
+	style   style
+	results []checker.BucketResult
+}
+
+func (t *tableWriter) Begin() error {
+	printHeader()
+	return nil
+}
+
+func (t *tableWriter) Write(result checker.BucketResult) error {
+	printResult(result, t.style)
+	t.results = append(t.results, result)
+	return nil
+}
+
+func (t *tableWriter) End() error {
+	printLegend()
+	printRiskSummary(t.results, t.style)
+	return nil
+}
+
+// jsonWriter collects all results and writes them as one JSON array at the end
+type jsonWriter struct {
+	w       io.Writer
+	results []checker.BucketResult
+}
+
+func (j *jsonWriter) Begin() error {
+	j.results = []checker.BucketResult{}
+	return nil
+}
+
+func (j *jsonWriter) Write(result checker.BucketResult) error {
+	j.results = append(j.results, result)
+	return nil
+}
+
+func (j *jsonWriter) End() error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j.results)
+}
+
+// jsonLinesWriter writes one JSON object per bucket as soon as it is done
+type jsonLinesWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonLinesWriter) Begin() error {
+	return nil
+}
+
+func (j *jsonLinesWriter) Write(result checker.BucketResult) error {
+	return j.enc.Encode(result)
+}
+
+func (j *jsonLinesWriter) End() error {
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"io"
+
+	"s3-check/internal/checker"
+)
+
+// csvWriter writes the permission matrix as CSV or TSV, one row per bucket,
+// with the same columns as the table and no color codes
+type csvWriter struct {
+	w *csv.Writer
+	// details adds the existence verdict and an error code column per check
+	details bool
+}
+
+func newCSVWriter(w io.Writer, comma rune, details bool) *csvWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	return &csvWriter{w: cw, details: details}
+}
+
+func (c *csvWriter) Begin() error {
+	header := []string{"BUCKET", "REGION", "RISK"}
+	for _, col := range resultColumns {
+		header = append(header, col.header)
+	}
+	if c.details {
+		header = append(header, "EXISTENCE")
+		for _, col := range resultColumns {
+			header = append(header, col.header+"-CODE")
+		}
+	}
+	return c.writeRow(header)
+}
+
+func (c *csvWriter) Write(result checker.BucketResult) error {
+	row := []string{result.BucketName, result.Region, string(result.Risk)}
+	for _, col := range resultColumns {
+		row = append(row, col.cellText(result))
+	}
+	if c.details {
+		row = append(row, string(result.Existence))
+		for _, col := range resultColumns {
+			row = append(row, col.check(result).ErrorCode)
+		}
+	}
+	return c.writeRow(row)
+}
+
+func (c *csvWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// writeRow flushes after every row so results stream as the scan runs
+func (c *csvWriter) writeRow(row []string) error {
+	if err := c.w.Write(row); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"s3-check/internal/checker"
+)
+
+func TestCSVHeader(t *testing.T) {
+	want := []string{"BUCKET", "REGION", "RISK"}
+	for _, col := range resultColumns {
+		want = append(want, col.header)
+	}
+
+	for _, details := range []bool{false, true} {
+		var buf bytes.Buffer
+		writeAll(t, newCSVWriter(&buf, ',', details), sampleResults())
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := rows[0]
+		if details {
+			if header[len(want)] != "EXISTENCE" || header[len(want)+1] != resultColumns[0].header+"-CODE" {
+				t.Errorf("details header = %v", header[len(want):])
+			}
+			header = header[:len(want)]
+		}
+		if !reflect.DeepEqual(header, want) {
+			t.Errorf("header (details %v) = %v, want %v", details, header, want)
+		}
+		if len(rows) != 1+len(sampleResults()) {
+			t.Errorf("got %d rows, want a header and one per result", len(rows))
+		}
+	}
+}
+
+func TestCSVQuoting(t *testing.T) {
+	result := checker.BucketResult{
+		BucketName: `odd,"name"`,
+		Region:     "us-east-1\tx",
+		HeadBucket: checker.Check{Status: checker.StatusError, ErrorCode: "Code, with \"quotes\"\tand tab"},
+	}
+	for _, comma := range []rune{',', '\t'} {
+		var buf bytes.Buffer
+		writeAll(t, newCSVWriter(&buf, comma, true), []checker.BucketResult{result})
+
+		reader := csv.NewReader(strings.NewReader(buf.String()))
+		reader.Comma = comma
+		rows, err := reader.ReadAll()
+		if err != nil {
+			t.Fatalf("comma %q: %v\n%s", comma, err, buf.String())
+		}
+		row := rows[1]
+		if row[0] != result.BucketName || row[1] != result.Region {
+			t.Errorf("comma %q: bucket, region = %q, %q", comma, row[0], row[1])
+		}
+		if code := row[len(row)-len(resultColumns)]; code != result.HeadBucket.ErrorCode {
+			t.Errorf("comma %q: HEAD error code = %q, want %q", comma, code, result.HeadBucket.ErrorCode)
+		}
+		if !strings.Contains(buf.String(), `"odd,""name"""`) {
+			t.Errorf("comma %q: bucket name not quoted with doubled quotes:\n%s", comma, buf.String())
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"s3-check/internal/checker"
+)
+
+// The report is a single static file: the stylesheet and script are inlined
+// so it can be mailed or attached to a ticket as is
+//
+//go:embed report
+var reportAssets embed.FS
+
+var reportTemplate = template.Must(template.ParseFS(reportAssets, "report/report.html"))
+
+// severity is a checker.Severity as shown on report badges
+type severity struct {
+	Name  string
+	Class string
+	Rank  int
+}
+
+func reportSeverity(s checker.Severity) severity {
+	name := strings.ToLower(string(s))
+	return severity{Name: name, Class: name, Rank: s.Rank()}
+}
+
+// htmlWriter collects results and renders the HTML report at the end
+type htmlWriter struct {
+	w       io.Writer
+	results []checker.BucketResult
+}
+
+type reportData struct {
+	Generated string
+	Summary   reportSummary
+	Columns   []string
+	Rows      []reportRow
+	// Span is the number of matrix columns, for the detail pane
+	Span int
+	CSS  template.CSS
+	JS   template.JS
+}
+
+type reportSummary struct {
+	Buckets      int
+	WithFindings int
+	Errors       int
+	Severities   []reportCount
+}
+
+type reportCount struct {
+	severity
+	Count int
+}
+
+type reportRow struct {
+	Bucket    string
+	Region    string
+	Existence checker.Existence
+	Duration  string
+	Risk      severity
+	Cells     []reportCell
+	Findings  []reportFinding
+	Grants    []string
+	ACLGrants []string
+	Checks    []reportCheck
+	// PAB describes the Block Public Access flags per level
+	PAB []string
+}
+
+type reportCell struct {
+	Text  string
+	Class string
+	Title string
+}
+
+type reportFinding struct {
+	severity
+	Text string
+}
+
+type reportCheck struct {
+	Name  string
+	Class string
+	Check checker.Check
+}
+
+func (h *htmlWriter) Begin() error {
+	return nil
+}
+
+func (h *htmlWriter) Write(result checker.BucketResult) error {
+	h.results = append(h.results, result)
+	return nil
+}
+
+func (h *htmlWriter) End() error {
+	css, err := reportAssets.ReadFile("report/report.css")
+	if err != nil {
+		return err
+	}
+	js, err := reportAssets.ReadFile("report/report.js")
+	if err != nil {
+		return err
+	}
+
+	data := reportData{
+		Generated: time.Now().UTC().Format(time.RFC1123),
+		Span:      len(resultColumns) + 3,
+		CSS:       template.CSS(css),
+		JS:        template.JS(js),
+	}
+	for _, col := range resultColumns {
+		data.Columns = append(data.Columns, col.header)
+	}
+
+	counts := make(map[checker.Severity]int)
+	for _, result := range h.results {
+		row := reportRowFor(result)
+		data.Rows = append(data.Rows, row)
+
+		data.Summary.Buckets++
+		if len(result.Findings) > 0 {
+			data.Summary.WithFindings++
+			counts[result.Risk]++
+		}
+		for _, c := range row.Checks {
+			if c.Check.Status == checker.StatusError {
+				data.Summary.Errors++
+			}
+		}
+	}
+	for _, sev := range checker.Severities {
+		data.Summary.Severities = append(data.Summary.Severities, reportCount{severity: reportSeverity(sev), Count: counts[sev]})
+	}
+
+	return reportTemplate.Execute(h.w, data)
+}
+
+func reportRowFor(result checker.BucketResult) reportRow {
+	row := reportRow{
+		Bucket:    result.BucketName,
+		Region:    result.Region,
+		Existence: result.Existence,
+		Risk:      reportSeverity(result.Risk),
+	}
+	if !result.StartedAt.IsZero() && !result.FinishedAt.IsZero() {
+		row.Duration = result.FinishedAt.Sub(result.StartedAt).Round(time.Millisecond).String()
+	}
+
+	for _, col := range resultColumns {
+		check := col.check(result)
+		title := check.ErrorCode
+		if check.Detail != "" {
+			if title != "" {
+				title += ": "
+			}
+			title += check.Detail
+		}
+		row.Cells = append(row.Cells, reportCell{
+			Text:  col.cellText(result),
+			Class: statusClass(check.Status),
+			Title: title,
+		})
+		row.Checks = append(row.Checks, reportCheck{
+			Name:  col.header,
+			Class: statusClass(check.Status),
+			Check: check,
+		})
+	}
+
+	for _, finding := range result.Findings {
+		row.Findings = append(row.Findings, reportFinding{
+			severity: reportSeverity(finding.Severity),
+			Text:     finding.Check + ": " + finding.Summary,
+		})
+	}
+	for _, grant := range result.PolicyGrants {
+		row.Grants = append(row.Grants, grant.String())
+	}
+	for _, grant := range result.ACLGrants {
+		row.ACLGrants = append(row.ACLGrants, grant.String())
+	}
+	bpa := result.BlockPublicAccess
+	for _, level := range []struct {
+		name  string
+		flags *checker.PublicAccessFlags
+	}{{"bucket", bpa.Bucket}, {"account", bpa.Account}} {
+		if level.flags != nil {
+			row.PAB = append(row.PAB, level.name+": "+publicAccessText(*level.flags))
+		}
+	}
+	return row
+}
+
+// publicAccessText lists the Block Public Access flags that are set
+func publicAccessText(flags checker.PublicAccessFlags) string {
+	if names := flags.Names(); len(names) > 0 {
+		return strings.Join(names, ", ")
+	}
+	return "none"
+}
+
+// statusClass is the CSS class suffix for a status, e.g. st-not_found or st-na
+func statusClass(status checker.Status) string {
+	return strings.ToLower(strings.ReplaceAll(string(status), "/", ""))
+}
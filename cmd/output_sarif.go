@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"s3-check/internal/checker"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "s3-check"
+)
+
+// sarifRule describes the rule reported for findings on one check
+type sarifRule struct {
+	id   string
+	name string
+	help string
+}
+
+// sarifRules holds the rule metadata for each check in checker.RiskRules
+var sarifRules = map[string]sarifRule{
+	"ANON-WRITE": {
+		id:   "S3C001",
+		name: "PublicWrite",
+		help: "Anyone can upload objects to the bucket. Remove public write grants from the bucket ACL and policy, and enable Block Public Access.",
+	},
+	"ANON-DEL": {
+		id:   "S3C002",
+		name: "PublicDelete",
+		help: "Anyone can delete objects from the bucket. Remove public write grants from the bucket ACL and policy, and enable Block Public Access.",
+	},
+	"PUT-ACL": {
+		id:   "S3C003",
+		name: "WritableACL",
+		help: "The scanning identity can rewrite the bucket ACL and so grant itself or the public any access. Restrict s3:PutBucketAcl or disable ACLs with Object Ownership set to BucketOwnerEnforced.",
+	},
+	"ANON-GET": {
+		id:   "S3C004",
+		name: "PublicRead",
+		help: "Anyone can read objects from the bucket. Remove public read grants from the bucket ACL and policy unless the bucket is meant to be public.",
+	},
+	"ANON-LIST": {
+		id:   "S3C005",
+		name: "PublicList",
+		help: "Anyone can list the keys in the bucket. Remove s3:ListBucket for the public from the bucket ACL and policy.",
+	},
+	"ACL-PUBLIC-WRITE": {
+		id:   "S3C006",
+		name: "PublicWriteACLGrant",
+		help: "The bucket ACL grants WRITE, WRITE_ACP or FULL_CONTROL to the AllUsers or AuthenticatedUsers group. Remove the grant, or disable ACLs with Object Ownership set to BucketOwnerEnforced.",
+	},
+	"ACL-CROSS-ACCOUNT": {
+		id:   "S3C007",
+		name: "CrossAccountWriteACLGrant",
+		help: "The bucket ACL grants WRITE, WRITE_ACP or FULL_CONTROL to another account. Remove the grant unless that account is trusted, and prefer a bucket policy for cross-account access.",
+	},
+	"ACL-PUBLIC-READ": {
+		id:   "S3C008",
+		name: "PublicReadACLGrant",
+		help: "The bucket ACL grants READ or READ_ACP to the AllUsers or AuthenticatedUsers group. Remove the grant unless the bucket is meant to be public.",
+	},
+	"ACL-LOG-DELIVERY": {
+		id:   "S3C009",
+		name: "LogDeliveryACLGrant",
+		help: "The bucket ACL grants access to the S3 LogDelivery group, which legacy server access logging needs. Remove it if the bucket is not a logging target, or switch log delivery to a bucket policy.",
+	},
+}
+
+// sarifLevel maps a severity to the SARIF level and the 0-10
+// security-severity score code-scanning dashboards rank alerts by
+func sarifLevel(severity checker.Severity) (level, securitySeverity string) {
+	switch severity {
+	case checker.SeverityCritical:
+		return "error", "9.8"
+	case checker.SeverityHigh:
+		return "error", "8.1"
+	case checker.SeverityMedium:
+		return "warning", "5.3"
+	case checker.SeverityLow:
+		return "note", "3.1"
+	}
+	return "note", "0.0"
+}
+
+// sarifWriter collects findings and writes a SARIF 2.1.0 log at the end
+type sarifWriter struct {
+	w       io.Writer
+	results []sarifResult
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string               `json:"name"`
+	Rules []sarifReportingRule `json:"rules"`
+}
+
+type sarifReportingRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	Help                 sarifMessage      `json:"help"`
+	DefaultConfiguration sarifConfig       `json:"defaultConfiguration"`
+	Properties           map[string]string `json:"properties"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	// PartialFingerprints lets dashboards track the same finding across runs
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+func (s *sarifWriter) Begin() error {
+	s.results = []sarifResult{}
+	return nil
+}
+
+func (s *sarifWriter) Write(result checker.BucketResult) error {
+	for _, finding := range result.Findings {
+		rule := sarifRules[finding.Check]
+		level, _ := sarifLevel(finding.Severity)
+		message := fmt.Sprintf("%s: s3://%s", finding.Summary, result.BucketName)
+		if result.Region != "" {
+			message += fmt.Sprintf(" (%s)", result.Region)
+		}
+		s.results = append(s.results, sarifResult{
+			RuleID:    rule.id,
+			RuleIndex: riskRuleIndex(finding.Check),
+			Level:     level,
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "s3://" + result.BucketName},
+				},
+				LogicalLocations: []sarifLogicalLocation{{Name: result.BucketName, Kind: "resource"}},
+			}},
+			PartialFingerprints: map[string]string{"bucketRule/v1": result.BucketName + "/" + rule.id},
+		})
+	}
+	return nil
+}
+
+// riskRuleIndex is the position of a check's rule in the driver's rule list
+func riskRuleIndex(check string) int {
+	for i, rule := range checker.RiskRules {
+		if rule.Check == check {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *sarifWriter) End() error {
+	rules := make([]sarifReportingRule, len(checker.RiskRules))
+	for i, risk := range checker.RiskRules {
+		rule := sarifRules[risk.Check]
+		level, securitySeverity := sarifLevel(risk.Severity)
+		rules[i] = sarifReportingRule{
+			ID:                   rule.id,
+			Name:                 rule.name,
+			ShortDescription:     sarifMessage{Text: risk.Summary},
+			Help:                 sarifMessage{Text: rule.help},
+			DefaultConfiguration: sarifConfig{Level: level},
+			Properties:           map[string]string{"security-severity": securitySeverity},
+		}
+	}
+
+	enc := json.NewEncoder(s.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:  toolName,
+				Rules: rules,
+			}},
+			Results: s.results,
+		}},
+	})
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"s3-check/internal/checker"
+)
+
+func TestSARIFRuleIndexAndLevels(t *testing.T) {
+	var buf bytes.Buffer
+	results := sampleResults()
+	results = append(results, checker.BucketResult{
+		BucketName: "acl-bucket",
+		Findings: []checker.Finding{
+			{Check: "ACL-LOG-DELIVERY", Severity: checker.SeverityLow, Summary: "LogDelivery group grant"},
+			{Check: "PUT-ACL", Severity: checker.SeverityHigh, Summary: "ACL can be rewritten"},
+		},
+	})
+	writeAll(t, &sarifWriter{w: &buf}, results)
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("version %q with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 4 {
+		t.Fatalf("got %d results, want one per finding (4)", len(run.Results))
+	}
+
+	wantLevel := map[string]string{"ANON-WRITE": "error", "ANON-GET": "warning", "ACL-LOG-DELIVERY": "note", "PUT-ACL": "error"}
+	i := 0
+	for _, result := range results {
+		for _, finding := range result.Findings {
+			got := run.Results[i]
+			i++
+			if got.RuleIndex < 0 || got.RuleIndex >= len(run.Tool.Driver.Rules) {
+				t.Errorf("%s: ruleIndex %d out of range", finding.Check, got.RuleIndex)
+				continue
+			}
+			if rule := run.Tool.Driver.Rules[got.RuleIndex]; rule.ID != got.RuleID || got.RuleID != sarifRules[finding.Check].id {
+				t.Errorf("%s: ruleIndex %d points at %s, ruleId is %s", finding.Check, got.RuleIndex, rule.ID, got.RuleID)
+			}
+			if got.Level != wantLevel[finding.Check] {
+				t.Errorf("%s: level %q, want %q", finding.Check, got.Level, wantLevel[finding.Check])
+			}
+		}
+	}
+}
+
+func TestSARIFRulesCoverRiskRules(t *testing.T) {
+	for _, risk := range checker.RiskRules {
+		if sarifRules[risk.Check].id == "" {
+			t.Errorf("no SARIF rule for %s", risk.Check)
+		}
+	}
+}
+
+func TestSARIFLevel(t *testing.T) {
+	for severity, want := range map[checker.Severity]string{
+		checker.SeverityCritical: "error",
+		checker.SeverityHigh:     "error",
+		checker.SeverityMedium:   "warning",
+		checker.SeverityLow:      "note",
+		checker.SeverityNone:     "note",
+	} {
+		if level, _ := sarifLevel(severity); level != want {
+			t.Errorf("sarifLevel(%s) = %q, want %q", severity, level, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"s3-check/internal/checker"
+)
+
+// sampleResults are two buckets, one with findings and a multi-line detail
+func sampleResults() []checker.BucketResult {
+	return []checker.BucketResult{
+		{
+			BucketName: "public-bucket",
+			Region:     "eu-west-1",
+			Existence:  checker.ExistenceExists,
+			HeadBucket: checker.Check{Status: checker.StatusOK},
+			AnonGet:    checker.Check{Status: checker.StatusOK},
+			AnonWrite:  checker.Check{Status: checker.StatusOK},
+			AuthList:   checker.Check{Status: checker.StatusDenied, ErrorCode: "AccessDenied", HTTPStatus: 403, Detail: "line one\nline two"},
+			Risk:       checker.SeverityCritical,
+			Findings: []checker.Finding{
+				{Check: "ANON-WRITE", Severity: checker.SeverityCritical, Summary: "Anyone can upload objects"},
+				{Check: "ANON-GET", Severity: checker.SeverityMedium, Summary: "Bucket objects are publicly readable"},
+			},
+		},
+		{
+			BucketName: "missing-bucket",
+			Existence:  checker.ExistenceNotFound,
+			HeadBucket: checker.Check{Status: checker.StatusNotFound, ErrorCode: "NoSuchBucket", HTTPStatus: 404},
+			Risk:       checker.SeverityNone,
+		},
+	}
+}
+
+func writeAll(t *testing.T, w resultWriter, results []checker.BucketResult) {
+	t.Helper()
+	if err := w.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if err := w.Write(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.End(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newResultWriter("jsonl", &buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := sampleResults()
+	writeAll(t, w, results)
+
+	var lines []map[string]json.RawMessage
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v\n%s", len(lines)+1, err, scanner.Text())
+		}
+		lines = append(lines, record)
+	}
+	if len(lines) != len(results) {
+		t.Fatalf("got %d lines, want one per result (%d)", len(lines), len(results))
+	}
+
+	for _, field := range []string{
+		"bucket", "region", "existence", "head_bucket", "public_access_block", "block_public_access",
+		"get_policy", "get_ownership_controls", "get_acl", "put_acl", "anon_get", "auth_get",
+		"anon_list", "auth_list", "anon_list_versions", "auth_list_versions",
+		"anon_write", "auth_write", "anon_delete", "auth_delete", "risk", "started_at", "finished_at",
+	} {
+		if _, ok := lines[0][field]; !ok {
+			t.Errorf("field %q missing", field)
+		}
+	}
+	if string(lines[0]["bucket"]) != `"public-bucket"` || string(lines[1]["bucket"]) != `"missing-bucket"` {
+		t.Errorf("buckets = %s, %s", lines[0]["bucket"], lines[1]["bucket"])
+	}
+
+	var authList map[string]any
+	if err := json.Unmarshal(lines[0]["auth_list"], &authList); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"status", "error_code", "http_status", "detail", "count"} {
+		if _, ok := authList[field]; !ok {
+			t.Errorf("check field %q missing from %v", field, authList)
+		}
+	}
+
+	var findings []map[string]any
+	if err := json.Unmarshal(lines[0]["findings"], &findings); err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[0]["check"] != "ANON-WRITE" || findings[0]["severity"] != "CRITICAL" || findings[0]["summary"] == nil {
+		t.Errorf("findings = %v", findings)
+	}
+}
@@ -0,0 +1,54 @@
+(function () {
+  var table = document.getElementById("matrix");
+  var filter = document.getElementById("filter");
+  var findingsOnly = document.getElementById("findings-only");
+  var buckets = Array.prototype.slice.call(table.tBodies);
+
+  // Toggle the evidence pane under a bucket row
+  buckets.forEach(function (body) {
+    var row = body.rows[0];
+    var detail = body.rows[1];
+    function toggle() { detail.hidden = !detail.hidden; }
+    row.addEventListener("click", toggle);
+    row.addEventListener("keydown", function (e) {
+      if (e.key === "Enter" || e.key === " ") { e.preventDefault(); toggle(); }
+    });
+  });
+
+  // Show only buckets whose row text matches every filter term
+  function applyFilter() {
+    var terms = filter.value.toLowerCase().split(/\s+/).filter(Boolean);
+    buckets.forEach(function (body) {
+      var text = body.rows[0].textContent.toLowerCase();
+      var match = terms.every(function (t) { return text.indexOf(t) !== -1; });
+      if (findingsOnly.checked && !body.classList.contains("has-findings")) {
+        match = false;
+      }
+      body.hidden = !match;
+    });
+  }
+  filter.addEventListener("input", applyFilter);
+  findingsOnly.addEventListener("change", applyFilter);
+
+  // Sort buckets by a column; the risk column sorts by severity rank
+  var headers = table.tHead.rows[0].cells;
+  Array.prototype.forEach.call(headers, function (th, index) {
+    th.addEventListener("click", function () {
+      var desc = th.classList.contains("asc");
+      Array.prototype.forEach.call(headers, function (h) { h.classList.remove("asc", "desc"); });
+      th.classList.add(desc ? "desc" : "asc");
+
+      function key(body) {
+        var cell = body.rows[0].cells[index];
+        if (th.dataset.sort === "rank") { return Number(cell.dataset.rank); }
+        return cell.textContent.trim().toLowerCase();
+      }
+      buckets.sort(function (a, b) {
+        var ka = key(a), kb = key(b);
+        var cmp = ka < kb ? -1 : ka > kb ? 1 : 0;
+        return desc ? -cmp : cmp;
+      });
+      buckets.forEach(function (body) { table.appendChild(body); });
+    });
+  });
+})();
@@ -16,5 +16,6 @@ func Execute() error {
 
 func init() {
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(enumCmd)
 }
 
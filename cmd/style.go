@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"s3-check/internal/checker"
+)
+
+const (
+	colorReset   = "\033[0m"
+	colorRed     = "\033[31m"
+	colorGreen   = "\033[32m"
+	colorYellow  = "\033[33m"
+	colorBlue    = "\033[34m"
+	colorMagenta = "\033[35m"
+	colorCyan    = "\033[36m"
+	colorGray    = "\033[90m"
+)
+
+// style decorates table cells. Text is padded before it is styled, so styles
+// must not change its visible width.
+type style interface {
+	status(status checker.Status, text string) string
+	risk(risk checker.Severity, text string) string
+}
+
+// ansiStyle colors cells with ANSI escape codes
+type ansiStyle struct{}
+
+func (ansiStyle) status(status checker.Status, text string) string {
+	var color string
+	switch status {
+	case checker.StatusOK:
+		color = colorGreen
+	case checker.StatusDenied:
+		color = colorRed
+	case checker.StatusError:
+		color = colorMagenta
+	case checker.StatusUnknown:
+		color = colorYellow
+	case checker.StatusNotFound:
+		color = colorCyan
+	default: // SKIPPED, N/A
+		color = colorGray
+	}
+	return color + text + colorReset
+}
+
+func (ansiStyle) risk(risk checker.Severity, text string) string {
+	color := colorGray
+	switch risk {
+	case checker.SeverityCritical, checker.SeverityHigh:
+		color = colorRed
+	case checker.SeverityMedium:
+		color = colorYellow
+	case checker.SeverityLow:
+		color = colorBlue
+	}
+	return color + text + colorReset
+}
+
+// plainStyle leaves cells as they are, for files, pipes and NO_COLOR
+type plainStyle struct{}
+
+func (plainStyle) status(_ checker.Status, text string) string { return text }
+
+func (plainStyle) risk(_ checker.Severity, text string) string { return text }
+
+var colorModes = []string{"auto", "always", "never"}
+
+// styleFor picks the table style for --color. In auto mode color is used
+// only when out is a terminal and NO_COLOR (https://no-color.org) is unset.
+func styleFor(mode string, out *os.File) (style, error) {
+	switch mode {
+	case "always":
+		return ansiStyle{}, nil
+	case "never":
+		return plainStyle{}, nil
+	case "auto", "":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !isTerminal(out) {
+			return plainStyle{}, nil
+		}
+		return ansiStyle{}, nil
+	}
+	return nil, fmt.Errorf("unknown color mode %q (want one of %v)", mode, colorModes)
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// pad left-aligns text in a cell of the given width
+func pad(text string, width int) string {
+	if len(text) >= width {
+		return text
+	}
+	return text + strings.Repeat(" ", width-len(text))
+}
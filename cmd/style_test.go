@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"s3-check/internal/checker"
+)
+
+func TestANSIStatusColors(t *testing.T) {
+	// SKIPPED and N/A both mean the check did not run and share a color
+	seen := map[string]checker.Status{}
+	for _, status := range []checker.Status{
+		checker.StatusOK, checker.StatusDenied, checker.StatusError,
+		checker.StatusUnknown, checker.StatusNotFound, checker.StatusSkipped,
+	} {
+		styled := ansiStyle{}.status(status, "x")
+		color := strings.TrimSuffix(styled, "x"+colorReset)
+		if other, ok := seen[color]; ok {
+			t.Errorf("%s has the same color as %s", status, other)
+		}
+		seen[color] = status
+	}
+}
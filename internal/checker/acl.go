@@ -0,0 +1,155 @@
+package checker
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"s3-check/internal/s3client"
+)
+
+// ACLGrantee classifies who an ACL grant is for
+type ACLGrantee string
+
+const (
+	// GranteeAllUsers is everyone, including anonymous callers
+	GranteeAllUsers ACLGrantee = "AllUsers"
+	// GranteeAuthenticatedUsers is any AWS account, not just the owner's
+	GranteeAuthenticatedUsers ACLGrantee = "AuthenticatedUsers"
+	// GranteeLogDelivery is the group S3 server access logs are written as
+	GranteeLogDelivery ACLGrantee = "LogDelivery"
+	// GranteeCanonicalUser is another account, by canonical user ID
+	GranteeCanonicalUser ACLGrantee = "CanonicalUser"
+	// GranteeEmail is another account, by email address
+	GranteeEmail ACLGrantee = "AmazonCustomerByEmail"
+	// GranteeOtherGroup is any group S3 adds beyond the three above
+	GranteeOtherGroup ACLGrantee = "Group"
+)
+
+// groupGrantees maps the predefined group URIs to their grantee
+var groupGrantees = map[string]ACLGrantee{
+	"http://acs.amazonaws.com/groups/global/AllUsers":           GranteeAllUsers,
+	"http://acs.amazonaws.com/groups/global/AuthenticatedUsers": GranteeAuthenticatedUsers,
+	"http://acs.amazonaws.com/groups/s3/LogDelivery":            GranteeLogDelivery,
+}
+
+// ACLGrant is a grant in the bucket ACL to anyone but the owner
+type ACLGrant struct {
+	Grantee ACLGrantee `json:"grantee"`
+	// ID is the canonical user ID, email address or group URI
+	ID string `json:"id"`
+	// Permission is READ, WRITE, READ_ACP, WRITE_ACP or FULL_CONTROL
+	Permission string `json:"permission"`
+}
+
+func (g ACLGrant) String() string {
+	switch g.Grantee {
+	case GranteeCanonicalUser, GranteeEmail, GranteeOtherGroup:
+		return fmt.Sprintf("%s %s %s", g.Grantee, g.ID, g.Permission)
+	}
+	return fmt.Sprintf("%s %s", g.Grantee, g.Permission)
+}
+
+var (
+	readPermissions  = []string{"READ", "READ_ACP"}
+	writePermissions = []string{"WRITE", "WRITE_ACP", "FULL_CONTROL"}
+	allPermissions   = []string{"READ", "READ_ACP", "WRITE", "WRITE_ACP", "FULL_CONTROL"}
+)
+
+func publicGroup(g ACLGrant) bool {
+	return g.Grantee == GranteeAllUsers || g.Grantee == GranteeAuthenticatedUsers
+}
+
+func otherAccount(g ACLGrant) bool {
+	return g.Grantee == GranteeCanonicalUser || g.Grantee == GranteeEmail
+}
+
+func logDelivery(g ACLGrant) bool {
+	return g.Grantee == GranteeLogDelivery
+}
+
+// aclGranted raises a finding when the ACL grants one of permissions to a
+// grantee who matches. Public grants do not count while Block Public Access
+// ignores public ACLs.
+func aclGranted(who func(ACLGrant) bool, permissions []string) func(BucketResult) bool {
+	return func(r BucketResult) bool {
+		for _, grant := range r.ACLGrants {
+			if !who(grant) || (publicGroup(grant) && r.BlockPublicAccess.ACLsIgnored()) {
+				continue
+			}
+			for _, permission := range permissions {
+				if grant.Permission == permission {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// checkGetACL reads the bucket ACL and returns its owner and the grants it
+// makes to anyone else
+func (c *Checker) checkGetACL(ctx context.Context, bucketName string) (Check, string, []ACLGrant) {
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpGetBucketAcl, Bucket: bucketName})
+	if err != nil || !resp.OK() {
+		c.logFailure("GET-ACL", bucketName, resp, err)
+		return failedCheck(resp, err), "", nil
+	}
+
+	owner, grants, err := parseACL(resp.Body)
+	if err != nil {
+		c.logFailure("GET-ACL", bucketName, nil, err)
+		return Check{Status: StatusError, Detail: err.Error()}, "", nil
+	}
+	if len(grants) == 0 {
+		return Check{Status: StatusOK, Detail: "no grants to others"}, owner, nil
+	}
+	parts := make([]string, len(grants))
+	for i, grant := range grants {
+		parts[i] = grant.String()
+	}
+	return Check{Status: StatusOK, Detail: "grants: " + strings.Join(parts, ", "), Count: len(grants)}, owner, grants
+}
+
+// parseACL reads an AccessControlPolicy document. The grantee type is taken
+// from which identifier is set rather than the xsi:type attribute.
+func parseACL(body []byte) (string, []ACLGrant, error) {
+	var policy struct {
+		Owner struct {
+			ID string `xml:"ID"`
+		} `xml:"Owner"`
+		Grants []struct {
+			Grantee struct {
+				ID           string `xml:"ID"`
+				URI          string `xml:"URI"`
+				EmailAddress string `xml:"EmailAddress"`
+			} `xml:"Grantee"`
+			Permission string `xml:"Permission"`
+		} `xml:"AccessControlList>Grant"`
+	}
+	if err := xml.Unmarshal(body, &policy); err != nil {
+		return "", nil, fmt.Errorf("parsing ACL: %w", err)
+	}
+
+	var grants []ACLGrant
+	for _, g := range policy.Grants {
+		grant := ACLGrant{Permission: g.Permission}
+		switch {
+		case g.Grantee.URI != "":
+			grant.ID = g.Grantee.URI
+			grant.Grantee = groupGrantees[g.Grantee.URI]
+			if grant.Grantee == "" {
+				grant.Grantee = GranteeOtherGroup
+			}
+		case g.Grantee.EmailAddress != "":
+			grant.Grantee, grant.ID = GranteeEmail, g.Grantee.EmailAddress
+		case g.Grantee.ID == policy.Owner.ID:
+			continue
+		default:
+			grant.Grantee, grant.ID = GranteeCanonicalUser, g.Grantee.ID
+		}
+		grants = append(grants, grant)
+	}
+	return policy.Owner.ID, grants, nil
+}
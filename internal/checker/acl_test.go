@@ -0,0 +1,87 @@
+package checker
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3fake"
+)
+
+const ownerID = "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be"
+
+const aclDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Owner><ID>` + ownerID + `</ID><DisplayName>owner</DisplayName></Owner>
+  <AccessControlList>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>` + ownerID + `</ID></Grantee>
+      <Permission>FULL_CONTROL</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee>
+      <Permission>READ</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/s3/LogDelivery</URI></Grantee>
+      <Permission>WRITE</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>other</ID></Grantee>
+      <Permission>WRITE_ACP</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="AmazonCustomerByEmail"><EmailAddress>someone@example.com</EmailAddress></Grantee>
+      <Permission>READ_ACP</Permission>
+    </Grant>
+  </AccessControlList>
+</AccessControlPolicy>`
+
+func TestParseACL(t *testing.T) {
+	owner, grants, err := parseACL([]byte(aclDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner != ownerID {
+		t.Errorf("owner = %q, want %q", owner, ownerID)
+	}
+	want := []ACLGrant{
+		{Grantee: GranteeAllUsers, ID: "http://acs.amazonaws.com/groups/global/AllUsers", Permission: "READ"},
+		{Grantee: GranteeLogDelivery, ID: "http://acs.amazonaws.com/groups/s3/LogDelivery", Permission: "WRITE"},
+		{Grantee: GranteeCanonicalUser, ID: "other", Permission: "WRITE_ACP"},
+		{Grantee: GranteeEmail, ID: "someone@example.com", Permission: "READ_ACP"},
+	}
+	if !reflect.DeepEqual(grants, want) {
+		t.Errorf("grants = %+v, want %+v", grants, want)
+	}
+}
+
+func TestParseACLInvalid(t *testing.T) {
+	if _, _, err := parseACL([]byte("not xml <")); err == nil {
+		t.Error("parseACL accepted a broken document")
+	}
+}
+
+func TestACLFindings(t *testing.T) {
+	f := s3fake.New().
+		Handle("acl", s3client.OpHeadBucket, s3fake.Respond(s3fake.Status(http.StatusOK))).
+		Handle("acl", s3client.OpGetBucketAcl, s3fake.Respond(s3fake.OK(aclDocument)))
+	result := checkFake(t, f, "acl")
+
+	if result.GetACL.Count != 4 {
+		t.Errorf("GET-ACL count = %d, want 4", result.GetACL.Count)
+	}
+	got := make(map[string]bool)
+	for _, finding := range result.Findings {
+		got[finding.Check] = true
+	}
+	for _, check := range []string{"ACL-PUBLIC-READ", "ACL-CROSS-ACCOUNT", "ACL-LOG-DELIVERY"} {
+		if !got[check] {
+			t.Errorf("no %s finding in %+v", check, result.Findings)
+		}
+	}
+	if got["ACL-PUBLIC-WRITE"] {
+		t.Error("ACL-PUBLIC-WRITE raised for a read-only public grant")
+	}
+}
@@ -2,557 +2,748 @@ package checker
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"sync"
 	"time"
-)
 
-const (
-	// Delay between bucket checks to avoid rate limiting
-	bucketCheckDelay = 100 * time.Millisecond
+	"s3-check/internal/policy"
+	"s3-check/internal/ratelimit"
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
 )
 
+// DefaultConcurrency is the number of buckets checked at the same time
+const DefaultConcurrency = 5
+
+// Transport sends S3 API requests. s3client.Client (native HTTP) and
+// s3client.CLI (aws s3api) are the two implementations; s3fake provides a
+// scripted one.
+type Transport interface {
+	Do(ctx context.Context, req *s3client.Request) (*s3client.Response, error)
+}
+
+// Option configures a Checker
+type Option func(*Checker)
+
+// WithTransport replaces the default native HTTP transport
+func WithTransport(t Transport) Option {
+	return func(c *Checker) {
+		c.transport = t
+	}
+}
+
 type Checker struct {
-	ctx     context.Context
-	verbose bool
+	transport Transport
+	regions   regionCache
+	// preflights holds HEAD bucket verdicts from ProbeBuckets
+	preflights preflightCache
+	verbose    bool
+	// concurrency is the number of buckets checked at the same time
+	concurrency int
+	// ordered delivers stream results in input order instead of completion order
+	ordered bool
+	// limiter and bucketLimiters throttle every request; nil means unlimited
+	limiter        *ratelimit.Limiter
+	bucketLimiters *ratelimit.Keyed
+	// maxRetries is how often a throttled request is retried
+	maxRetries int
+	// opTimeout bounds each request attempt, bucketTimeout all checks of a bucket; zero means none
+	opTimeout     time.Duration
+	bucketTimeout time.Duration
+	// created tracks test objects until they are deleted again
+	created objectRegistry
+	// accountID is the caller's account as given to SetAccountID; account
+	// holds the one in use, looked up from STS when none was given
+	accountID string
+	account   accountInfo
+	// configChecks adds the configuration read checks to every bucket
+	configChecks bool
 }
 
 type BucketResult struct {
-	BucketName string
-	GetACL     string
-	PutACL     string
-	AnonGet    string
-	AuthGet    string
-	AnonWrite  string
-	AuthWrite  string
-	AnonDel    string
-	AuthDel    string
+	BucketName string `json:"bucket"`
+	// Region is where the bucket lives; empty if it could not be determined
+	Region string `json:"region"`
+	// Existence is the verdict of the HEAD bucket pre-flight in HeadBucket
+	Existence  Existence `json:"existence"`
+	HeadBucket Check     `json:"head_bucket"`
+	// PublicAccessBlock is GetPublicAccessBlock on the bucket; Count holds the
+	// number of flags in effect at bucket or account level
+	PublicAccessBlock Check             `json:"public_access_block"`
+	BlockPublicAccess BlockPublicAccess `json:"block_public_access"`
+	// Policy is GetBucketPolicy; Count holds the number of PolicyGrants
+	Policy       Check          `json:"get_policy"`
+	PolicyGrants []policy.Grant `json:"policy_grants,omitempty"`
+	// Ownership is GetBucketOwnershipControls; ObjectOwnership is the mode in
+	// effect, empty when it could not be read
+	Ownership       Check           `json:"get_ownership_controls"`
+	ObjectOwnership ObjectOwnership `json:"object_ownership,omitempty"`
+	// GetACL is GetBucketAcl; Count holds the number of ACLGrants
+	GetACL Check `json:"get_acl"`
+	// ACLOwner is the canonical ID of the bucket owner, ACLGrants the grants to anyone else
+	ACLOwner  string     `json:"acl_owner,omitempty"`
+	ACLGrants []ACLGrant `json:"acl_grants,omitempty"`
+	PutACL    Check      `json:"put_acl"`
+	AnonGet   Check      `json:"anon_get"`
+	AuthGet   Check      `json:"auth_get"`
+	// Listing checks; Count holds the number of keys (or versions) on the first page
+	AnonList         Check `json:"anon_list"`
+	AuthList         Check `json:"auth_list"`
+	AnonListVersions Check `json:"anon_list_versions"`
+	AuthListVersions Check `json:"auth_list_versions"`
+	AnonWrite        Check `json:"anon_write"`
+	AuthWrite        Check `json:"auth_write"`
+	AnonDel          Check `json:"anon_delete"`
+	AuthDel          Check `json:"auth_delete"`
+	// Config holds the configuration read checks; nil unless enabled with SetConfigChecks
+	Config *BucketConfig `json:"config,omitempty"`
+	// Risk is the most severe of Findings, the risky permissions that were allowed
+	Risk     Severity  `json:"risk"`
+	Findings []Finding `json:"findings,omitempty"`
+	// StartedAt and FinishedAt bracket the checks of this bucket
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
 }
 
-func NewChecker() (*Checker, error) {
-	return &Checker{
-		ctx:     context.Background(),
-		verbose: false,
-	}, nil
+func NewChecker(opts ...Option) (*Checker, error) {
+	c := &Checker{
+		verbose:     false,
+		concurrency: DefaultConcurrency,
+		limiter:     ratelimit.New(DefaultRPS, burstFor(DefaultRPS)),
+		maxRetries:  DefaultMaxRetries,
+		opTimeout:   DefaultOpTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.transport == nil {
+		c.transport = s3client.NewClient()
+	}
+	return c, nil
 }
 
 func (c *Checker) SetVerbose(v bool) {
 	c.verbose = v
 }
 
-func (c *Checker) ListAllBuckets() ([]string, error) {
-	// Use AWS CLI to list buckets
-	cmd := exec.Command("aws", "s3api", "list-buckets", "--query", "Buckets[].Name", "--output", "text")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("error listing buckets: %w", err)
+// SetConcurrency sets how many buckets are checked at the same time
+func (c *Checker) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
 	}
-
-	// Parse output - each bucket name on a new line
-	bucketNames := strings.Fields(string(output))
-	return bucketNames, nil
+	c.concurrency = n
 }
 
-func (c *Checker) CheckBuckets(bucketNames []string) ([]BucketResult, error) {
-	results := make([]BucketResult, 0, len(bucketNames))
-
-	for _, bucketName := range bucketNames {
-		result := BucketResult{
-			BucketName: bucketName,
-		}
-
-		// Check GET-ACL
-		result.GetACL = c.checkGetACL(bucketName)
+// SetTimeouts bounds each request and all the checks of one bucket; zero disables a bound
+func (c *Checker) SetTimeouts(op, bucket time.Duration) {
+	c.opTimeout = op
+	c.bucketTimeout = bucket
+}
 
-		// Check PUT-ACL
-		result.PutACL = c.checkPutACL(bucketName)
+// SetConfigChecks adds the configuration read checks (CORS, website,
+// logging, versioning, encryption, lifecycle and tagging) to every bucket
+func (c *Checker) SetConfigChecks(enabled bool) {
+	c.configChecks = enabled
+}
 
-		// Check ANON-GET (Anonymous GET)
-		result.AnonGet = c.checkAnonGet(bucketName)
+// SetOrdered makes CheckBucketsStream deliver results in input order
+func (c *Checker) SetOrdered(ordered bool) {
+	c.ordered = ordered
+}
 
-		// Check AUTH-GET (Authenticated GET)
-		result.AuthGet = c.checkAuthGet(bucketName)
+func (c *Checker) ListAllBuckets(ctx context.Context) ([]string, error) {
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpListBuckets})
+	if err != nil {
+		return nil, fmt.Errorf("error listing buckets: %w", err)
+	}
+	if !resp.OK() {
+		return nil, fmt.Errorf("error listing buckets: %d %s", resp.StatusCode, strings.TrimSpace(string(resp.Body)))
+	}
 
-		// Check ANON-WRITE (Anonymous WRITE)
-		result.AnonWrite = c.checkAnonWrite(bucketName)
+	var listing struct {
+		Buckets []struct {
+			Name string
+		} `xml:"Buckets>Bucket"`
+	}
+	if err := xml.Unmarshal(resp.Body, &listing); err != nil {
+		return nil, fmt.Errorf("error parsing bucket list: %w", err)
+	}
 
-		// Check AUTH-WRITE (Authenticated WRITE)
-		result.AuthWrite = c.checkAuthWrite(bucketName)
+	bucketNames := make([]string, 0, len(listing.Buckets))
+	for _, bucket := range listing.Buckets {
+		bucketNames = append(bucketNames, bucket.Name)
+	}
+	return bucketNames, nil
+}
 
-		// Check ANON-DEL (Anonymous DELETE)
-		result.AnonDel = c.checkAnonDel(bucketName)
+// permissionChecks returns every permission check of the result except the HEAD bucket pre-flight
+func (r *BucketResult) permissionChecks() []*Check {
+	return []*Check{
+		&r.PublicAccessBlock, &r.Policy, &r.Ownership,
+		&r.GetACL, &r.PutACL,
+		&r.AnonGet, &r.AuthGet,
+		&r.AnonList, &r.AuthList, &r.AnonListVersions, &r.AuthListVersions,
+		&r.AnonWrite, &r.AuthWrite,
+		&r.AnonDel, &r.AuthDel,
+	}
+}
 
-		// Check AUTH-DEL (Authenticated DELETE)
-		result.AuthDel = c.checkAuthDel(bucketName)
+// skipRemaining marks every permission check as skipped
+func (r *BucketResult) skipRemaining(detail string) {
+	for _, check := range r.permissionChecks() {
+		*check = skipped(detail)
+	}
+}
 
+// CheckBuckets checks buckets and returns the results in input order
+func (c *Checker) CheckBuckets(ctx context.Context, bucketNames []string) ([]BucketResult, error) {
+	results := make([]BucketResult, 0, len(bucketNames))
+	err := c.run(ctx, bucketNames, true, c.checkBucket, func(result BucketResult) {
 		results = append(results, result)
-	}
+	})
+	return results, err
+}
 
-	return results, nil
+// CheckBucketsStream checks buckets and calls the callback function for each result as it's processed.
+// Up to the configured concurrency, buckets are checked at the same time, and all permission checks
+// for a bucket run in parallel. Results arrive in completion order unless ordered output is enabled.
+// The callback is never called concurrently.
+//
+// When ctx is cancelled no new buckets are started; buckets already in flight
+// are reported with whatever their checks returned, and ctx's error is returned.
+func (c *Checker) CheckBucketsStream(ctx context.Context, bucketNames []string, callback func(BucketResult)) error {
+	return c.run(ctx, bucketNames, c.ordered, c.checkBucket, callback)
 }
 
-// CheckBucketsStream checks buckets and calls the callback function for each result as it's processed
-// All permission checks for a bucket are run in parallel, then waits 1 second before the next bucket
-func (c *Checker) CheckBucketsStream(bucketNames []string, callback func(BucketResult)) error {
-	for i, bucketName := range bucketNames {
-		// Trim whitespace from bucket name
-		bucketName = strings.TrimSpace(bucketName)
-		if bucketName == "" {
-			continue // Skip empty bucket names
-		}
+// ProbeBuckets runs only the HEAD bucket pre-flight, with the same concurrency
+// and rate limits as the full checks. Results carry HeadBucket, Existence and
+// the region if S3 revealed it; the permission checks are left empty. Regions
+// and settled verdicts found here are remembered, so a later
+// CheckBucketsStream does not send HEAD bucket again.
+func (c *Checker) ProbeBuckets(ctx context.Context, bucketNames []string, callback func(BucketResult)) error {
+	return c.run(ctx, bucketNames, false, c.probeBucket, callback)
+}
 
-		// Create a fresh context for each bucket to avoid cancellation issues
-		ctx := context.Background()
+func (c *Checker) probeBucket(ctx context.Context, bucketName string) BucketResult {
+	result := BucketResult{BucketName: bucketName, StartedAt: time.Now().UTC()}
+	result.HeadBucket, result.Existence = c.checkHeadBucket(ctx, bucketName)
+	if result.Existence != ExistenceUnknown {
+		c.preflights.put(bucketName, result.HeadBucket, result.Existence)
+	}
+	result.Region, _ = c.regions.get(bucketName)
+	result.FinishedAt = time.Now().UTC()
+	return result
+}
 
-		result := BucketResult{
-			BucketName: bucketName,
+// run feeds the buckets to a bounded pool of workers, each running check
+func (c *Checker) run(ctx context.Context, bucketNames []string, ordered bool, check func(context.Context, string) BucketResult, callback func(BucketResult)) error {
+	// Trim whitespace and skip empty bucket names
+	names := make([]string, 0, len(bucketNames))
+	for _, bucketName := range bucketNames {
+		if bucketName = strings.TrimSpace(bucketName); bucketName != "" {
+			names = append(names, bucketName)
 		}
+	}
 
-		// Use WaitGroup to wait for all parallel checks to complete
-		var wg sync.WaitGroup
-		wg.Add(8) // 8 permission checks
+	workers := c.concurrency
+	if workers > len(names) {
+		workers = len(names)
+	}
+	if workers < 1 {
+		workers = 1
+	}
 
-		// Use channels to safely collect results from goroutines
-		type checkResult struct {
-			field string
-			value string
-		}
-		resultsChan := make(chan checkResult, 8)
+	type indexedResult struct {
+		index  int
+		result BucketResult
+	}
+	jobs := make(chan int)
+	results := make(chan indexedResult)
 
-		// Run all checks in parallel
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
 		go func() {
 			defer wg.Done()
-			resultsChan <- checkResult{"GetACL", c.checkGetACLWithContext(ctx, bucketName)}
+			for i := range jobs {
+				results <- indexedResult{i, check(ctx, names[i])}
+			}
 		}()
+	}
 
-		go func() {
-			defer wg.Done()
-			resultsChan <- checkResult{"PutACL", c.checkPutACLWithContext(ctx, bucketName)}
-		}()
+	go func() {
+		defer close(jobs)
+		for i := range names {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 
-		go func() {
-			defer wg.Done()
-			resultsChan <- checkResult{"AnonGet", c.checkAnonGet(bucketName)}
-		}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-		go func() {
-			defer wg.Done()
-			resultsChan <- checkResult{"AuthGet", c.checkAuthGet(bucketName)}
-		}()
+	if !ordered {
+		for res := range results {
+			callback(res.result)
+		}
+		return ctx.Err()
+	}
 
-		go func() {
-			defer wg.Done()
-			resultsChan <- checkResult{"AnonWrite", c.checkAnonWrite(bucketName)}
-		}()
+	// Hold back results that finish early until everything before them is done
+	pending := make(map[int]BucketResult)
+	next := 0
+	for res := range results {
+		pending[res.index] = res.result
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			callback(result)
+			next++
+		}
+	}
+	return ctx.Err()
+}
 
-		go func() {
-			defer wg.Done()
-			resultsChan <- checkResult{"AuthWrite", c.checkAuthWrite(bucketName)}
-		}()
+// checkBucket runs the HEAD pre-flight and then every permission check for one bucket
+func (c *Checker) checkBucket(ctx context.Context, bucketName string) BucketResult {
+	if c.bucketTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.bucketTimeout)
+		defer cancel()
+	}
 
-		go func() {
-			defer wg.Done()
-			resultsChan <- checkResult{"AnonDel", c.checkAnonDel(bucketName)}
-		}()
+	result := BucketResult{BucketName: bucketName, StartedAt: time.Now().UTC()}
 
+	// HEAD bucket first: it tells us whether the bucket exists and, with
+	// the region resolved, every check goes to the right endpoint
+	result.HeadBucket, result.Existence = c.headBucket(ctx, bucketName)
+	if result.Existence == ExistenceNotFound {
+		result.skipRemaining("bucket does not exist")
+		if c.configChecks {
+			result.Config = skippedConfig("bucket does not exist")
+		}
+		result.score()
+		result.FinishedAt = time.Now().UTC()
+		return result
+	}
+	result.Region = c.ResolveRegion(ctx, bucketName)
+	// Block Public Access, the policy and Object Ownership are read before the
+	// parallel checks: the anonymous checks interpret their probes with the
+	// first two, and the ACL checks do not apply when ACLs are disabled
+	result.PublicAccessBlock, result.BlockPublicAccess = c.checkPublicAccessBlock(ctx, bucketName)
+	result.Policy, result.PolicyGrants = c.checkPolicy(ctx, bucketName)
+	result.Ownership, result.ObjectOwnership = c.checkOwnership(ctx, bucketName)
+	bpa := result.BlockPublicAccess
+	aclsOff := result.ObjectOwnership.ACLsDisabled()
+
+	// Use WaitGroup to wait for all parallel checks to complete
+	var wg sync.WaitGroup
+	wg.Add(12) // 12 permission checks
+
+	// Use channels to safely collect results from goroutines
+	type checkResult struct {
+		field string
+		value Check
+	}
+	resultsChan := make(chan checkResult, 12)
+
+	// Run all checks in parallel. The ACL goroutine also sets aclOwner and
+	// aclGrants, which are safe to read once resultsChan is closed.
+	var aclOwner string
+	var aclGrants []ACLGrant
+	go func() {
+		defer wg.Done()
+		if aclsOff {
+			resultsChan <- checkResult{"GetACL", aclsDisabled()}
+			return
+		}
+		var check Check
+		check, aclOwner, aclGrants = c.checkGetACL(ctx, bucketName)
+		resultsChan <- checkResult{"GetACL", check}
+	}()
+
+	go func() {
+		defer wg.Done()
+		if aclsOff {
+			resultsChan <- checkResult{"PutACL", aclsDisabled()}
+			return
+		}
+		resultsChan <- checkResult{"PutACL", c.checkPutACL(ctx, bucketName)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AnonGet", c.checkAnonGet(ctx, bucketName, bpa, result.Policy, result.PolicyGrants)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AuthGet", c.checkAuthGet(ctx, bucketName)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AnonList", c.checkList(ctx, bucketName, true)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AuthList", c.checkList(ctx, bucketName, false)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AnonListVersions", c.checkListVersions(ctx, bucketName, true)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AuthListVersions", c.checkListVersions(ctx, bucketName, false)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AnonWrite", c.checkAnonWrite(ctx, bucketName, bpa)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AuthWrite", c.checkAuthWrite(ctx, bucketName)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AnonDel", c.checkAnonDel(ctx, bucketName, bpa)}
+	}()
+
+	go func() {
+		defer wg.Done()
+		resultsChan <- checkResult{"AuthDel", c.checkAuthDel(ctx, bucketName)}
+	}()
+
+	// The configuration reads run alongside and report through config,
+	// which is safe to read once resultsChan is closed
+	var config *BucketConfig
+	if c.configChecks {
+		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			resultsChan <- checkResult{"AuthDel", c.checkAuthDel(bucketName)}
+			config = c.checkConfig(ctx, bucketName)
 		}()
+	}
 
-		// Wait for all checks to complete and collect results
-		go func() {
-			wg.Wait()
-			close(resultsChan)
-		}()
-
-		// Collect all results
-		for res := range resultsChan {
-			switch res.field {
-			case "GetACL":
-				result.GetACL = res.value
-			case "PutACL":
-				result.PutACL = res.value
-			case "AnonGet":
-				result.AnonGet = res.value
-			case "AuthGet":
-				result.AuthGet = res.value
-			case "AnonWrite":
-				result.AnonWrite = res.value
-			case "AuthWrite":
-				result.AuthWrite = res.value
-			case "AnonDel":
-				result.AnonDel = res.value
-			case "AuthDel":
-				result.AuthDel = res.value
-			}
-		}
-
-		// Call callback immediately with the result
-		callback(result)
-
-		// Wait 100ms before processing next bucket (except for the last one)
-		if i < len(bucketNames)-1 {
-			time.Sleep(100 * time.Millisecond)
+	// Wait for all checks to complete and collect results
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	// Collect all results
+	for res := range resultsChan {
+		switch res.field {
+		case "GetACL":
+			result.GetACL = res.value
+		case "PutACL":
+			result.PutACL = res.value
+		case "AnonGet":
+			result.AnonGet = res.value
+		case "AuthGet":
+			result.AuthGet = res.value
+		case "AnonList":
+			result.AnonList = res.value
+		case "AuthList":
+			result.AuthList = res.value
+		case "AnonListVersions":
+			result.AnonListVersions = res.value
+		case "AuthListVersions":
+			result.AuthListVersions = res.value
+		case "AnonWrite":
+			result.AnonWrite = res.value
+		case "AuthWrite":
+			result.AuthWrite = res.value
+		case "AnonDel":
+			result.AnonDel = res.value
+		case "AuthDel":
+			result.AuthDel = res.value
 		}
 	}
 
-	return nil
-}
+	result.ACLOwner, result.ACLGrants = aclOwner, aclGrants
+	result.Config = config
 
-func (c *Checker) checkGetACL(bucketName string) string {
-	return c.checkGetACLWithContext(c.ctx, bucketName)
+	result.score()
+	result.FinishedAt = time.Now().UTC()
+	return result
 }
 
-func (c *Checker) checkGetACLWithContext(ctx context.Context, bucketName string) string {
-	// Use AWS CLI which handles regions automatically
-	cmd := exec.Command("aws", "s3api", "get-bucket-acl", "--bucket", bucketName)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[GET-ACL] %s: %v\n", bucketName, string(output))
-		}
-		errStr := string(output)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+func (c *Checker) checkPutACL(ctx context.Context, bucketName string) Check {
+	// Get the current ACL, then try to put it back (no-op change)
+	getResp, err := c.do(ctx, &s3client.Request{Op: s3client.OpGetBucketAcl, Bucket: bucketName})
+	if err != nil || !getResp.OK() {
+		c.logFailure("PUT-ACL", bucketName+" (get)", getResp, err)
+		return setupFailed(failedCheck(getResp, err), "could not read current ACL")
 	}
-	return "OK"
-}
 
-func (c *Checker) checkPutACL(bucketName string) string {
-	return c.checkPutACLWithContext(c.ctx, bucketName)
+	putResp, err := c.do(ctx, &s3client.Request{
+		Op:     s3client.OpPutBucketAcl,
+		Bucket: bucketName,
+		Body:   getResp.Body,
+	})
+	if err != nil || !putResp.OK() {
+		c.logFailure("PUT-ACL", bucketName+" (put)", putResp, err)
+		return failedCheck(putResp, err)
+	}
+	return ok()
 }
 
-func (c *Checker) checkPutACLWithContext(ctx context.Context, bucketName string) string {
-	// Use AWS CLI: get ACL first, then try to put it back
-	// Get ACL
-	getCmd := exec.Command("aws", "s3api", "get-bucket-acl", "--bucket", bucketName)
-	getOutput, err := getCmd.CombinedOutput()
+func (c *Checker) checkAnonGet(ctx context.Context, bucketName string, bpa BlockPublicAccess, policyCheck Check, grants []policy.Grant) Check {
+	// Try to access with anonymous credentials; Block Public Access only
+	// explains the outcome, the probe has the final word
+	testKey := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	resp, err := c.do(ctx, &s3client.Request{
+		Op:        s3client.OpHeadObject,
+		Bucket:    bucketName,
+		Key:       testKey,
+		Anonymous: true,
+	})
 	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[PUT-ACL] %s (get): %v\n", bucketName, string(getOutput))
-		}
-		errStr := string(getOutput)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+		c.logFailure("ANON-GET", bucketName, resp, err)
+		return failedCheck(resp, err)
 	}
-
-	// Save ACL to temp file
-	tmpFile := fmt.Sprintf("/tmp/acl-%s-%d.json", bucketName, time.Now().UnixNano())
-	err = os.WriteFile(tmpFile, getOutput, 0644)
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[PUT-ACL] %s (write temp): %v\n", bucketName, err)
-		}
-		return "DENIED"
+	switch {
+	case resp.OK():
+		return ok()
+	case s3err.Parse(resp).Category() == s3err.Absent:
+		return ok() // Anonymous access is allowed, just key doesn't exist
 	}
-	defer os.Remove(tmpFile)
-
-	// Try to put ACL back (no-op change)
-	putCmd := exec.Command("aws", "s3api", "put-bucket-acl", "--bucket", bucketName, "--access-control-policy", "file://"+tmpFile)
-	putOutput, err := putCmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[PUT-ACL] %s (put): %v\n", bucketName, string(putOutput))
-		}
-		errStr := string(putOutput)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
+	c.logFailure("ANON-GET", bucketName, resp, nil)
+	check := failedCheck(resp, nil)
+	if check.Status == StatusDenied && policyCheck.Status == StatusOK {
+		// Without s3:ListBucket S3 answers a HEAD on a missing key with 403,
+		// even on a public-read bucket, so a public grant in the policy wins
+		if fromPolicy := anonGetFromPolicy(bucketName, bpa, policyCheck, grants); fromPolicy.Status != StatusDenied {
+			return fromPolicy
 		}
-		return "DENIED"
 	}
-	return "OK"
+	if check.Status == StatusDenied || check.Status == StatusNotFound {
+		return bpa.explain(check)
+	}
+	// For other errors, fall back on what the bucket policy grants
+	return anonGetFromPolicy(bucketName, bpa, policyCheck, grants)
 }
 
-func (c *Checker) checkAnonGet(bucketName string) string {
-	// Check bucket policy and public access block settings
-	// Then try to access with anonymous credentials
+func (c *Checker) checkAuthGet(ctx context.Context, bucketName string) Check {
+	// Try to head a non-existent object
+	// 404/NoSuchKey = access allowed, 403 = denied
 	testKey := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpHeadObject, Bucket: bucketName, Key: testKey})
+	if err == nil && (resp.OK() || s3err.Parse(resp).Category() == s3err.Absent) {
+		return ok() // Access allowed, just key doesn't exist
+	}
+	c.logFailure("AUTH-GET", bucketName, resp, err)
+	return failedCheck(resp, err)
+}
 
-	// Check public access block using AWS CLI
-	pabCmd := exec.Command("aws", "s3api", "get-public-access-block", "--bucket", bucketName)
-	pabOutput, pabErr := pabCmd.CombinedOutput()
-	if pabErr != nil {
-		// Error might mean no PAB is configured (which is OK)
-		errStr := string(pabOutput)
-		if c.verbose && !strings.Contains(errStr, "NoSuchPublicAccessBlockConfiguration") {
-			fmt.Fprintf(os.Stderr, "[ANON-GET] %s (public-access-block): %v\n", bucketName, errStr)
-		}
-	} else {
-		// Parse JSON output to check if public access is blocked
-		pabStr := string(pabOutput)
-		if strings.Contains(pabStr, `"BlockPublicAcls": true`) ||
-			strings.Contains(pabStr, `"BlockPublicPolicy": true`) ||
-			strings.Contains(pabStr, `"IgnorePublicAcls": true`) ||
-			strings.Contains(pabStr, `"RestrictPublicBuckets": true`) {
-			return "DENIED"
-		}
+// checkList lists the first page of objects (ListObjectsV2)
+func (c *Checker) checkList(ctx context.Context, bucketName string, anonymous bool) Check {
+	tag := listTag("LIST", anonymous)
+	resp, err := c.do(ctx, &s3client.Request{
+		Op:        s3client.OpListObjectsV2,
+		Bucket:    bucketName,
+		Anonymous: anonymous,
+	})
+	if err != nil || !resp.OK() {
+		c.logFailure(tag, bucketName, resp, err)
+		return failedCheck(resp, err)
 	}
-	// If GetPublicAccessBlock returned an error, it might mean no PAB is configured (which is OK)
-	// or we don't have permission to check it (which we'll discover when trying anonymous access)
 
-	// Use AWS CLI with --no-sign-request for anonymous access
-	cmd := exec.Command("aws", "s3api", "head-object", "--bucket", bucketName, "--key", testKey, "--no-sign-request")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		errStr := string(output)
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[ANON-GET] %s: %v\n", bucketName, errStr)
-		}
-		if strings.Contains(errStr, "NoSuchKey") || strings.Contains(errStr, "404") {
-			return "OK" // Anonymous access is allowed, just key doesn't exist
-		}
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		// For other errors, try checking bucket policy as fallback
-		return c.checkBucketPolicyForAnonGet(bucketName)
+	var page struct {
+		Contents    []struct{ Key string }
+		IsTruncated bool
+	}
+	if err := xml.Unmarshal(resp.Body, &page); err != nil {
+		c.logFailure(tag, bucketName, nil, err)
+		return Check{Status: StatusError, Detail: "unreadable listing: " + err.Error()}
 	}
-	return "OK"
+	return listed(len(page.Contents), "keys", page.IsTruncated)
 }
 
-func (c *Checker) checkBucketPolicyForAnonGet(bucketName string) string {
-	// Check bucket policy for public read access using AWS CLI
-	cmd := exec.Command("aws", "s3api", "get-bucket-policy", "--bucket", bucketName)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[ANON-GET] %s (policy): %v\n", bucketName, string(output))
-		}
-		// If we can't get the policy, we can't determine anonymous access from policy
-		// Return DENIED as conservative default
-		return "DENIED"
+// checkListVersions lists the first page of object versions (ListObjectVersions)
+func (c *Checker) checkListVersions(ctx context.Context, bucketName string, anonymous bool) Check {
+	tag := listTag("VERS", anonymous)
+	resp, err := c.do(ctx, &s3client.Request{
+		Op:        s3client.OpListObjectVersions,
+		Bucket:    bucketName,
+		Anonymous: anonymous,
+	})
+	if err != nil || !resp.OK() {
+		c.logFailure(tag, bucketName, resp, err)
+		return failedCheck(resp, err)
 	}
 
-	// Parse JSON output - extract Policy field
-	policyStr := string(output)
-	// Simple check: if policy contains "Principal": "*", it might allow anonymous access
-	if strings.Contains(policyStr, `"Principal":"*"`) || strings.Contains(policyStr, `"Principal":{"AWS":"*"}`) {
-		if strings.Contains(policyStr, `"s3:GetObject"`) || strings.Contains(policyStr, `"s3:Get*"`) {
-			return "OK"
-		}
+	var page struct {
+		Versions      []struct{ Key string } `xml:"Version"`
+		DeleteMarkers []struct{ Key string } `xml:"DeleteMarker"`
+		IsTruncated   bool
+	}
+	if err := xml.Unmarshal(resp.Body, &page); err != nil {
+		c.logFailure(tag, bucketName, nil, err)
+		return Check{Status: StatusError, Detail: "unreadable listing: " + err.Error()}
 	}
-	// Policy exists but doesn't allow anonymous access
-	return "DENIED"
+	return listed(len(page.Versions)+len(page.DeleteMarkers), "versions", page.IsTruncated)
 }
 
-func (c *Checker) checkAuthGet(bucketName string) string {
-	// Use AWS CLI: try to head a non-existent object
-	// 404/NoSuchKey = access allowed, 403 = denied
-	testKey := fmt.Sprintf("test-%d", time.Now().UnixNano())
-	cmd := exec.Command("aws", "s3api", "head-object", "--bucket", bucketName, "--key", testKey)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		errStr := string(output)
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[AUTH-GET] %s: %v\n", bucketName, errStr)
-		}
-		if strings.Contains(errStr, "NoSuchKey") || strings.Contains(errStr, "404") {
-			return "OK" // Access allowed, just key doesn't exist
-		}
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+func listTag(name string, anonymous bool) string {
+	if anonymous {
+		return "ANON-" + name
 	}
-	return "OK"
+	return "AUTH-" + name
 }
 
-func (c *Checker) checkAnonWrite(bucketName string) string {
-	// Check public access block using AWS CLI
-	pabCmd := exec.Command("aws", "s3api", "get-public-access-block", "--bucket", bucketName)
-	pabOutput, pabErr := pabCmd.CombinedOutput()
-	if pabErr != nil {
-		// Error might mean no PAB is configured (which is OK)
-		errStr := string(pabOutput)
-		if c.verbose && !strings.Contains(errStr, "NoSuchPublicAccessBlockConfiguration") {
-			fmt.Fprintf(os.Stderr, "[ANON-WRITE] %s (public-access-block): %v\n", bucketName, errStr)
-		}
-	} else {
-		// Parse JSON output to check if public access is blocked
-		pabStr := string(pabOutput)
-		if strings.Contains(pabStr, `"BlockPublicAcls": true`) ||
-			strings.Contains(pabStr, `"BlockPublicPolicy": true`) ||
-			strings.Contains(pabStr, `"IgnorePublicAcls": true`) ||
-			strings.Contains(pabStr, `"RestrictPublicBuckets": true`) {
-			return "DENIED"
-		}
+func listed(count int, noun string, truncated bool) Check {
+	detail := fmt.Sprintf("%d %s on first page", count, noun)
+	if truncated {
+		detail += " (more available)"
 	}
+	return Check{Status: StatusOK, Count: count, Detail: detail}
+}
 
-	// Use AWS CLI with --no-sign-request for anonymous write
+func (c *Checker) checkAnonWrite(ctx context.Context, bucketName string, bpa BlockPublicAccess) Check {
 	testKey := fmt.Sprintf("test-anon-write-%d", time.Now().UnixNano())
-	tmpFile := fmt.Sprintf("/tmp/test-%s-%d", bucketName, time.Now().UnixNano())
-	err := os.WriteFile(tmpFile, []byte("test"), 0644)
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[ANON-WRITE] %s (write temp): %v\n", bucketName, err)
-		}
-		return "DENIED"
-	}
-	defer os.Remove(tmpFile)
-
-	cmd := exec.Command("aws", "s3api", "put-object", "--bucket", bucketName, "--key", testKey, "--body", tmpFile, "--no-sign-request")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[ANON-WRITE] %s: %v\n", bucketName, string(output))
-		}
-		errStr := string(output)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+	if check := c.putTestObject(ctx, "ANON-WRITE", bucketName, testKey, true); check.Status != StatusOK {
+		return bpa.explain(check)
 	}
 
 	// Clean up the test object
-	delCmd := exec.Command("aws", "s3api", "delete-object", "--bucket", bucketName, "--key", testKey, "--no-sign-request")
-	delCmd.Run() // Ignore cleanup errors
+	c.deleteObject(ctx, bucketName, testKey, true) // Ignore cleanup errors
 
-	return "OK"
+	return ok()
 }
 
-func (c *Checker) checkAuthWrite(bucketName string) string {
-	// Use AWS CLI: try to put an object
+func (c *Checker) checkAuthWrite(ctx context.Context, bucketName string) Check {
 	testKey := fmt.Sprintf("test-auth-write-%d", time.Now().UnixNano())
-	tmpFile := fmt.Sprintf("/tmp/test-%s-%d", bucketName, time.Now().UnixNano())
-	err := os.WriteFile(tmpFile, []byte("test"), 0644)
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[AUTH-WRITE] %s (write temp): %v\n", bucketName, err)
-		}
-		return "DENIED"
-	}
-	defer os.Remove(tmpFile)
-
-	cmd := exec.Command("aws", "s3api", "put-object", "--bucket", bucketName, "--key", testKey, "--body", tmpFile)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[AUTH-WRITE] %s (put): %v\n", bucketName, string(output))
-		}
-		errStr := string(output)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+	if check := c.putTestObject(ctx, "AUTH-WRITE", bucketName, testKey, false); check.Status != StatusOK {
+		return check
 	}
 
 	// Clean up the test object
-	delCmd := exec.Command("aws", "s3api", "delete-object", "--bucket", bucketName, "--key", testKey)
-	delCmd.Run() // Ignore cleanup errors
+	c.deleteObject(ctx, bucketName, testKey, false) // Ignore cleanup errors
 
-	return "OK"
+	return ok()
 }
 
-func (c *Checker) checkAnonDel(bucketName string) string {
-	// Check public access block using AWS CLI
-	pabCmd := exec.Command("aws", "s3api", "get-public-access-block", "--bucket", bucketName)
-	pabOutput, pabErr := pabCmd.CombinedOutput()
-	if pabErr != nil {
-		// Error might mean no PAB is configured (which is OK)
-		errStr := string(pabOutput)
-		if c.verbose && !strings.Contains(errStr, "NoSuchPublicAccessBlockConfiguration") {
-			fmt.Fprintf(os.Stderr, "[ANON-DEL] %s (public-access-block): %v\n", bucketName, errStr)
-		}
-	} else {
-		// Parse JSON output to check if public access is blocked
-		pabStr := string(pabOutput)
-		if strings.Contains(pabStr, `"BlockPublicAcls": true`) ||
-			strings.Contains(pabStr, `"BlockPublicPolicy": true`) ||
-			strings.Contains(pabStr, `"IgnorePublicAcls": true`) ||
-			strings.Contains(pabStr, `"RestrictPublicBuckets": true`) {
-			return "DENIED"
-		}
+func (c *Checker) checkAnonDel(ctx context.Context, bucketName string, bpa BlockPublicAccess) Check {
+	// First create a test object with the authenticated client
+	testKey := fmt.Sprintf("test-anon-del-%d", time.Now().UnixNano())
+	if check := c.putTestObject(ctx, "ANON-DEL", bucketName, testKey, false); check.Status != StatusOK {
+		return setupFailed(check, "could not create test object")
 	}
 
-	// First create a test object with authenticated client (using AWS CLI)
-	testKey := fmt.Sprintf("test-anon-del-%d", time.Now().UnixNano())
-	tmpFile := fmt.Sprintf("/tmp/test-%s-%d", bucketName, time.Now().UnixNano())
-	err := os.WriteFile(tmpFile, []byte("test"), 0644)
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[ANON-DEL] %s (write temp): %v\n", bucketName, err)
-		}
-		return "DENIED"
+	// Now try to delete it anonymously
+	resp, err := c.deleteObject(ctx, bucketName, testKey, true)
+	if err != nil || !resp.OK() {
+		c.logFailure("ANON-DEL", bucketName, resp, err)
+		// Clean up with authenticated client
+		c.deleteObject(ctx, bucketName, testKey, false)
+		return bpa.explain(failedCheck(resp, err))
 	}
-	defer os.Remove(tmpFile)
 
-	putCmd := exec.Command("aws", "s3api", "put-object", "--bucket", bucketName, "--key", testKey, "--body", tmpFile)
-	putOutput, err := putCmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[ANON-DEL] %s (create test object): %v\n", bucketName, string(putOutput))
-		}
-		return "DENIED"
+	return ok()
+}
+
+func (c *Checker) checkAuthDel(ctx context.Context, bucketName string) Check {
+	// Create test object, then try to delete it
+	testKey := fmt.Sprintf("test-auth-del-%d", time.Now().UnixNano())
+	if check := c.putTestObject(ctx, "AUTH-DEL", bucketName, testKey, false); check.Status != StatusOK {
+		return setupFailed(check, "could not create test object")
 	}
 
-	// Now try to delete it with anonymous credentials (using AWS CLI with --no-sign-request)
-	delCmd := exec.Command("aws", "s3api", "delete-object", "--bucket", bucketName, "--key", testKey, "--no-sign-request")
-	delOutput, err := delCmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[ANON-DEL] %s: %v\n", bucketName, string(delOutput))
-		}
-		// Clean up with authenticated client
-		cleanupCmd := exec.Command("aws", "s3api", "delete-object", "--bucket", bucketName, "--key", testKey)
-		cleanupCmd.Run()
-		errStr := string(delOutput)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+	resp, err := c.deleteObject(ctx, bucketName, testKey, false)
+	if err != nil || !resp.OK() {
+		c.logFailure("AUTH-DEL", bucketName+" (delete)", resp, err)
+		return failedCheck(resp, err)
 	}
 
-	return "OK"
+	return ok()
 }
 
-func (c *Checker) checkAuthDel(bucketName string) string {
-	// Use AWS CLI: create test object, then try to delete it
-	testKey := fmt.Sprintf("test-auth-del-%d", time.Now().UnixNano())
-	tmpFile := fmt.Sprintf("/tmp/test-%s-%d", bucketName, time.Now().UnixNano())
-	err := os.WriteFile(tmpFile, []byte("test"), 0644)
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[AUTH-DEL] %s (write temp): %v\n", bucketName, err)
-		}
-		return "DENIED"
+// putTestObject uploads a small test object
+func (c *Checker) putTestObject(ctx context.Context, tag, bucketName, key string, anonymous bool) Check {
+	// Register the key before sending: if the request is cancelled after S3
+	// stored the object, Cleanup still knows to delete it
+	c.created.add(bucketName, key)
+	resp, err := c.do(ctx, &s3client.Request{
+		Op:        s3client.OpPutObject,
+		Bucket:    bucketName,
+		Key:       key,
+		Body:      []byte("test"),
+		Anonymous: anonymous,
+	})
+	if err != nil || !resp.OK() {
+		// Only a refusal from S3, or a request that never left, proves
+		// nothing was stored
+		if (err == nil && resp.StatusCode >= 400 && resp.StatusCode < 500) || notSent(err) {
+			c.created.remove(bucketName, key)
+		}
+		c.logFailure(tag, bucketName+" (put)", resp, err)
+		return failedCheck(resp, err)
 	}
-	defer os.Remove(tmpFile)
+	return ok()
+}
 
-	// Create test object
-	putCmd := exec.Command("aws", "s3api", "put-object", "--bucket", bucketName, "--key", testKey, "--body", tmpFile)
-	putOutput, err := putCmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[AUTH-DEL] %s (put): %v\n", bucketName, string(putOutput))
-		}
-		errStr := string(putOutput)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+// notSent reports whether err shows a request never reached S3: it could not
+// be built or signed, or no connection was made
+func notSent(err error) bool {
+	if err == nil {
+		return false
 	}
+	if errors.Is(err, s3client.ErrNoCredentials) || errors.Is(err, s3client.ErrInvalidRequest) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
 
-	// Try to delete it
-	delCmd := exec.Command("aws", "s3api", "delete-object", "--bucket", bucketName, "--key", testKey)
-	delOutput, err := delCmd.CombinedOutput()
-	if err != nil {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "[AUTH-DEL] %s (delete): %v\n", bucketName, string(delOutput))
-		}
-		errStr := string(delOutput)
-		if strings.Contains(errStr, "AccessDenied") || strings.Contains(errStr, "403") || strings.Contains(errStr, "Forbidden") {
-			return "DENIED"
-		}
-		return "DENIED"
+func (c *Checker) deleteObject(ctx context.Context, bucketName, key string, anonymous bool) (*s3client.Response, error) {
+	resp, err := c.do(ctx, &s3client.Request{
+		Op:        s3client.OpDeleteObject,
+		Bucket:    bucketName,
+		Key:       key,
+		Anonymous: anonymous,
+	})
+	if err == nil && resp.OK() {
+		c.created.remove(bucketName, key)
 	}
+	return resp, err
+}
 
-	return "OK"
+// logFailure prints the raw response or transport error in verbose mode
+func (c *Checker) logFailure(tag, bucketName string, resp *s3client.Response, err error) {
+	if !c.verbose {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] %s: %v\n", tag, bucketName, err)
+		return
+	}
+	if resp != nil {
+		fmt.Fprintf(os.Stderr, "[%s] %s: %d %s\n", tag, bucketName, resp.StatusCode, strings.TrimSpace(string(resp.Body)))
+	}
 }
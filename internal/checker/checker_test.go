@@ -0,0 +1,228 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3fake"
+)
+
+// testAccount is the caller's account in tests, set so no STS call is made
+const testAccount = "111122223333"
+
+// checkFake runs every check on bucket against f
+func checkFake(t *testing.T, f *s3fake.Fake, bucket string) BucketResult {
+	t.Helper()
+	c, err := NewChecker(WithTransport(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRateLimit(0, 0)
+	c.SetAccountID(testAccount)
+	return c.checkBucket(context.Background(), bucket)
+}
+
+// openBucket scripts a bucket that allows every operation to everyone
+func openBucket(f *s3fake.Fake, bucket string) *s3fake.Fake {
+	return f.
+		Handle(bucket, s3client.OpHeadBucket, s3fake.Respond(s3fake.Status(http.StatusOK))).
+		Handle(bucket, s3client.OpGetPublicAccessBlock, s3fake.Respond(s3fake.Error(http.StatusNotFound, "NoSuchPublicAccessBlockConfiguration"))).
+		Handle(bucket, s3client.OpGetBucketPolicy, s3fake.Respond(s3fake.Error(http.StatusNotFound, "NoSuchBucketPolicy"))).
+		Handle(bucket, s3client.OpGetBucketOwnershipControls, s3fake.Respond(s3fake.Error(http.StatusNotFound, "OwnershipControlsNotFoundError"))).
+		Handle(bucket, s3client.OpGetBucketAcl, s3fake.Respond(s3fake.OK(`<AccessControlPolicy><Owner><ID>owner</ID></Owner><AccessControlList></AccessControlList></AccessControlPolicy>`))).
+		Handle(bucket, s3client.OpPutBucketAcl, s3fake.Respond(s3fake.OK(""))).
+		Handle(bucket, s3client.OpHeadObject, s3fake.Respond(s3fake.Status(http.StatusNotFound))).
+		Handle(bucket, s3client.OpListObjectsV2, s3fake.Respond(s3fake.OK(`<ListBucketResult><Contents><Key>a</Key></Contents><Contents><Key>b</Key></Contents></ListBucketResult>`))).
+		Handle(bucket, s3client.OpListObjectVersions, s3fake.Respond(s3fake.OK(`<ListVersionsResult><Version><Key>a</Key></Version></ListVersionsResult>`))).
+		Handle(bucket, s3client.OpPutObject, s3fake.Respond(s3fake.OK(""))).
+		Handle(bucket, s3client.OpDeleteObject, s3fake.Respond(s3fake.Status(http.StatusNoContent)))
+}
+
+// inRegion answers with a redirect to region unless the request was sent there
+func inRegion(region string, h s3fake.Handler) s3fake.Handler {
+	return func(req *s3client.Request) (*s3client.Response, error) {
+		if req.Region != region {
+			return s3fake.Redirect(region), nil
+		}
+		return h(req)
+	}
+}
+
+// checksByName lists the checks of a result under their column names
+func checksByName(r BucketResult) map[string]Check {
+	return map[string]Check{
+		"HEAD": r.HeadBucket, "PAB": r.PublicAccessBlock, "OWNERSHIP": r.Ownership, "POLICY": r.Policy,
+		"GET-ACL": r.GetACL, "PUT-ACL": r.PutACL, "ANON-GET": r.AnonGet, "AUTH-GET": r.AuthGet,
+		"ANON-LIST": r.AnonList, "AUTH-LIST": r.AuthList, "ANON-VERS": r.AnonListVersions, "AUTH-VERS": r.AuthListVersions,
+		"ANON-WRITE": r.AnonWrite, "AUTH-WRITE": r.AuthWrite, "ANON-DEL": r.AnonDel, "AUTH-DEL": r.AuthDel,
+	}
+}
+
+func TestCheckBucket(t *testing.T) {
+	tests := []struct {
+		name      string
+		bucket    string
+		fake      func() *s3fake.Fake
+		existence Existence
+		region    string
+		risk      Severity
+		// every is the status of all checks not listed in want
+		every Status
+		want  map[string]Status
+	}{
+		{
+			name:      "everything allowed",
+			bucket:    "open",
+			fake:      func() *s3fake.Fake { return openBucket(s3fake.New(), "open") },
+			existence: ExistenceExists,
+			risk:      SeverityCritical,
+			every:     StatusOK,
+		},
+		{
+			name:   "AccessDenied",
+			bucket: "locked",
+			fake: func() *s3fake.Fake {
+				return s3fake.New().Handle("locked", s3client.OpHeadBucket, s3fake.Respond(s3fake.Status(http.StatusOK)))
+			},
+			existence: ExistenceExists,
+			risk:      SeverityNone,
+			every:     StatusDenied,
+			// Checks that first set something up cannot tell a denial apart
+			want: map[string]Status{"HEAD": StatusOK, "PUT-ACL": StatusUnknown, "ANON-DEL": StatusUnknown, "AUTH-DEL": StatusUnknown},
+		},
+		{
+			name:   "NoSuchBucket",
+			bucket: "gone",
+			fake: func() *s3fake.Fake {
+				return s3fake.New().Handle("gone", "", s3fake.Respond(s3fake.NoSuchBucket()))
+			},
+			existence: ExistenceNotFound,
+			risk:      SeverityNone,
+			every:     StatusSkipped,
+			want:      map[string]Status{"HEAD": StatusNotFound},
+		},
+		{
+			name:   "PermanentRedirect",
+			bucket: "moved",
+			fake: func() *s3fake.Fake {
+				open := openBucket(s3fake.New(), "moved")
+				return s3fake.New().Handle("moved", "", inRegion("eu-west-1", func(req *s3client.Request) (*s3client.Response, error) {
+					return open.Do(context.Background(), req)
+				}))
+			},
+			existence: ExistenceExists,
+			region:    "eu-west-1",
+			risk:      SeverityCritical,
+			every:     StatusOK,
+		},
+		{
+			name:   "ACLs disabled",
+			bucket: "enforced",
+			fake: func() *s3fake.Fake {
+				return openBucket(s3fake.New(), "enforced").
+					Handle("enforced", s3client.OpGetBucketOwnershipControls, s3fake.Respond(s3fake.OK(
+						`<OwnershipControls><Rule><ObjectOwnership>BucketOwnerEnforced</ObjectOwnership></Rule></OwnershipControls>`)))
+			},
+			existence: ExistenceExists,
+			risk:      SeverityCritical,
+			every:     StatusOK,
+			want:      map[string]Status{"GET-ACL": StatusNA, "PUT-ACL": StatusNA},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.fake()
+			result := checkFake(t, f, tt.bucket)
+			if result.Existence != tt.existence {
+				t.Errorf("existence = %s, want %s", result.Existence, tt.existence)
+			}
+			if result.Region != tt.region {
+				t.Errorf("region = %q, want %q", result.Region, tt.region)
+			}
+			if result.Risk != tt.risk {
+				t.Errorf("risk = %s, want %s", result.Risk, tt.risk)
+			}
+			for name, check := range checksByName(result) {
+				want, ok := tt.want[name]
+				if !ok {
+					want = tt.every
+				}
+				if check.Status != want {
+					t.Errorf("%s = %s (%s), want %s", name, check.Status, check.Detail, want)
+				}
+			}
+		})
+	}
+}
+
+func TestACLsDisabledSendsNoACLRequests(t *testing.T) {
+	f := openBucket(s3fake.New(), "enforced").
+		Handle("enforced", s3client.OpGetBucketOwnershipControls, s3fake.Respond(s3fake.OK(
+			`<OwnershipControls><Rule><ObjectOwnership>BucketOwnerEnforced</ObjectOwnership></Rule></OwnershipControls>`)))
+	checkFake(t, f, "enforced")
+	for _, req := range f.Calls() {
+		if req.Op == s3client.OpGetBucketAcl || req.Op == s3client.OpPutBucketAcl {
+			t.Errorf("sent %s although ACLs are disabled", req.Op)
+		}
+	}
+}
+
+func TestRedirectRetriesInRegion(t *testing.T) {
+	open := openBucket(s3fake.New(), "moved")
+	f := s3fake.New().Handle("moved", "", inRegion("eu-west-1", func(req *s3client.Request) (*s3client.Response, error) {
+		return open.Do(context.Background(), req)
+	}))
+	checkFake(t, f, "moved")
+
+	// Only the first request goes to the wrong region; the rest use the cache
+	var redirected int
+	for _, req := range f.Calls() {
+		if req.Bucket == "moved" && req.Region != "eu-west-1" {
+			redirected++
+		}
+	}
+	if redirected != 1 {
+		t.Errorf("%d requests sent outside eu-west-1, want 1", redirected)
+	}
+}
+
+func TestProbeResultsAreReused(t *testing.T) {
+	// S3 names the region on HEAD bucket, so the checks need no other lookup
+	inEU := &s3client.Response{StatusCode: http.StatusOK, Header: http.Header{"X-Amz-Bucket-Region": {"eu-west-1"}}}
+	f := openBucket(s3fake.New(), "found").
+		Handle("found", s3client.OpHeadBucket, s3fake.Respond(inEU)).
+		Handle("flaky", s3client.OpHeadBucket, s3fake.Fail(errors.New("dial tcp: i/o timeout")))
+	c, err := NewChecker(WithTransport(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRateLimit(0, 0)
+	c.SetAccountID(testAccount)
+	ctx := context.Background()
+
+	existence := map[string]Existence{}
+	if err := c.ProbeBuckets(ctx, []string{"found", "flaky"}, func(r BucketResult) { existence[r.BucketName] = r.Existence }); err != nil {
+		t.Fatal(err)
+	}
+	if existence["found"] != ExistenceExists || existence["flaky"] != ExistenceUnknown {
+		t.Fatalf("existence = %v", existence)
+	}
+
+	if result := c.checkBucket(ctx, "found"); result.HeadBucket.Status != StatusOK {
+		t.Errorf("HEAD = %s, want the probe's OK", result.HeadBucket.Status)
+	}
+	c.checkBucket(ctx, "flaky")
+	heads := map[string]int{}
+	for _, req := range f.Calls() {
+		if req.Op == s3client.OpHeadBucket {
+			heads[req.Bucket]++
+		}
+	}
+	// The signed and anonymous probes of flaky settled nothing, so it is probed again
+	if heads["found"] != 1 || heads["flaky"] <= 2 {
+		t.Errorf("HEAD bucket requests = %v, want found once and flaky re-probed", heads)
+	}
+}
@@ -0,0 +1,75 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type testObject struct {
+	bucket string
+	key    string
+}
+
+// objectRegistry tracks the test objects the checks have written and not yet deleted
+type objectRegistry struct {
+	mu      sync.Mutex
+	objects map[testObject]struct{}
+}
+
+func (r *objectRegistry) add(bucketName, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.objects == nil {
+		r.objects = make(map[testObject]struct{})
+	}
+	r.objects[testObject{bucketName, key}] = struct{}{}
+}
+
+func (r *objectRegistry) remove(bucketName, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.objects, testObject{bucketName, key})
+}
+
+func (r *objectRegistry) list() []testObject {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	objects := make([]testObject, 0, len(r.objects))
+	for object := range r.objects {
+		objects = append(objects, object)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].bucket != objects[j].bucket {
+			return objects[i].bucket < objects[j].bucket
+		}
+		return objects[i].key < objects[j].key
+	})
+	return objects
+}
+
+// Cleanup deletes every test object the checks created but could not delete,
+// for example because the run was interrupted. It should be given a fresh
+// context, not the cancelled one. Objects that still cannot be deleted are
+// listed in the returned error.
+func (c *Checker) Cleanup(ctx context.Context) error {
+	var leftovers []string
+	for _, object := range c.created.list() {
+		// Try the authenticated client first; anonymously written objects may
+		// only be deletable anonymously
+		resp, err := c.deleteObject(ctx, object.bucket, object.key, false)
+		if err != nil || !resp.OK() {
+			resp, err = c.deleteObject(ctx, object.bucket, object.key, true)
+		}
+		if err != nil || !resp.OK() {
+			c.logFailure("CLEANUP", object.bucket+"/"+object.key, resp, err)
+			leftovers = append(leftovers, object.bucket+"/"+object.key)
+		}
+	}
+	if len(leftovers) > 0 {
+		return fmt.Errorf("could not delete test objects: %s", strings.Join(leftovers, ", "))
+	}
+	return nil
+}
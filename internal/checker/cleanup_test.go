@@ -0,0 +1,86 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3fake"
+)
+
+func TestPutTestObjectRegistersKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		put        s3fake.Handler
+		registered bool
+	}{
+		{"stored", s3fake.Respond(s3fake.OK("")), true},
+		{"refused", s3fake.Respond(s3fake.Error(http.StatusForbidden, "AccessDenied")), false},
+		// S3 may have stored the object before the answer was lost
+		{"cancelled", s3fake.Fail(context.Canceled), true},
+		{"server error", s3fake.Respond(s3fake.Error(http.StatusInternalServerError, "InternalError")), true},
+		// Requests that never reached S3 cannot have stored anything
+		{"no credentials", s3fake.Fail(fmt.Errorf("%w: instance role: timeout", s3client.ErrNoCredentials)), false},
+		{"invalid request", s3fake.Fail(fmt.Errorf("%w: bad URL", s3client.ErrInvalidRequest)), false},
+		{"DNS failure", s3fake.Fail(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "b.s3.amazonaws.com"}}), false},
+		{"connection refused", s3fake.Fail(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}), false},
+		{"connection reset", s3fake.Fail(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := s3fake.New().Handle("b", s3client.OpPutObject, tt.put)
+			c, err := NewChecker(WithTransport(f))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.SetRateLimit(0, 0)
+			c.SetMaxRetries(0)
+			c.putTestObject(context.Background(), "TEST", "b", "test-key", false)
+			if got := len(c.created.list()) == 1; got != tt.registered {
+				t.Errorf("registered = %v, want %v", got, tt.registered)
+			}
+		})
+	}
+}
+
+func TestCleanupDeletesRegisteredObjects(t *testing.T) {
+	f := s3fake.New().
+		Handle("b", s3client.OpPutObject, s3fake.Fail(errors.New("connection reset"))).
+		Handle("b", s3client.OpDeleteObject, s3fake.Respond(s3fake.Status(http.StatusNoContent)))
+	c, err := NewChecker(WithTransport(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRateLimit(0, 0)
+	c.SetMaxRetries(0)
+	c.putTestObject(context.Background(), "TEST", "b", "test-key", false)
+	if err := c.Cleanup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.created.list()) != 0 {
+		t.Error("object still registered after Cleanup")
+	}
+}
+
+func TestCleanupSkipsObjectsNeverSent(t *testing.T) {
+	f := s3fake.New().Handle("b", s3client.OpPutObject, s3fake.Fail(s3client.ErrNoCredentials))
+	c, err := NewChecker(WithTransport(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRateLimit(0, 0)
+	c.SetMaxRetries(0)
+	c.putTestObject(context.Background(), "TEST", "b", "test-key", false)
+	if err := c.Cleanup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range f.Calls() {
+		if req.Op == s3client.OpDeleteObject {
+			t.Errorf("Cleanup sent %s for an object that was never written", req.Op)
+		}
+	}
+}
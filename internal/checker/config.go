@@ -0,0 +1,345 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
+)
+
+// BucketConfig is what the configuration read checks found. Each entry is the
+// outcome of one Get call with a summary of the configuration it returned; a
+// bucket without the configuration is OK with the summary left empty.
+type BucketConfig struct {
+	CORS       CORSConfig       `json:"cors"`
+	Website    WebsiteConfig    `json:"website"`
+	Logging    LoggingConfig    `json:"logging"`
+	Versioning VersioningConfig `json:"versioning"`
+	Encryption EncryptionConfig `json:"encryption"`
+	Lifecycle  LifecycleConfig  `json:"lifecycle"`
+	Tagging    TaggingConfig    `json:"tagging"`
+}
+
+// skippedConfig is the configuration of a bucket whose checks were skipped
+func skippedConfig(detail string) *BucketConfig {
+	b := &BucketConfig{}
+	for _, check := range []*Check{
+		&b.CORS.Check, &b.Website.Check, &b.Logging.Check, &b.Versioning.Check,
+		&b.Encryption.Check, &b.Lifecycle.Check, &b.Tagging.Check,
+	} {
+		*check = skipped(detail)
+	}
+	return b
+}
+
+// CORSConfig is GetBucketCors; Count holds the number of rules
+type CORSConfig struct {
+	Check
+	Rules []CORSRule `json:"rules,omitempty"`
+}
+
+// CORSRule is one cross-origin rule
+type CORSRule struct {
+	AllowedOrigins []string `xml:"AllowedOrigin" json:"allowed_origins"`
+	AllowedMethods []string `xml:"AllowedMethod" json:"allowed_methods"`
+	AllowedHeaders []string `xml:"AllowedHeader" json:"allowed_headers,omitempty"`
+	ExposeHeaders  []string `xml:"ExposeHeader" json:"expose_headers,omitempty"`
+}
+
+// WebsiteConfig is GetBucketWebsite
+type WebsiteConfig struct {
+	Check
+	Enabled       bool   `json:"enabled"`
+	IndexDocument string `json:"index_document,omitempty"`
+	ErrorDocument string `json:"error_document,omitempty"`
+	// RedirectTo is the host every request is redirected to
+	RedirectTo   string `json:"redirect_to,omitempty"`
+	RoutingRules int    `json:"routing_rules,omitempty"`
+}
+
+// LoggingConfig is GetBucketLogging
+type LoggingConfig struct {
+	Check
+	Enabled      bool   `json:"enabled"`
+	TargetBucket string `json:"target_bucket,omitempty"`
+	TargetPrefix string `json:"target_prefix,omitempty"`
+}
+
+// VersioningStatus is a bucket's versioning state; empty when versioning was never enabled
+type VersioningStatus string
+
+const (
+	VersioningEnabled   VersioningStatus = "Enabled"
+	VersioningSuspended VersioningStatus = "Suspended"
+)
+
+// VersioningConfig is GetBucketVersioning
+type VersioningConfig struct {
+	Check
+	State     VersioningStatus `json:"state,omitempty"`
+	MFADelete bool             `json:"mfa_delete"`
+}
+
+// EncryptionConfig is GetBucketEncryption; Algorithm is empty when the bucket
+// has no default encryption
+type EncryptionConfig struct {
+	Check
+	// Algorithm is AES256 (SSE-S3), aws:kms (SSE-KMS) or aws:kms:dsse (DSSE-KMS)
+	Algorithm        string `json:"algorithm,omitempty"`
+	KMSKeyID         string `json:"kms_key_id,omitempty"`
+	BucketKeyEnabled bool   `json:"bucket_key_enabled"`
+}
+
+// LifecycleConfig is GetBucketLifecycleConfiguration; Count holds the number of rules
+type LifecycleConfig struct {
+	Check
+	Rules []LifecycleRule `json:"rules,omitempty"`
+}
+
+// LifecycleRule is one lifecycle rule
+type LifecycleRule struct {
+	ID      string `json:"id,omitempty"`
+	Enabled bool   `json:"enabled"`
+	Prefix  string `json:"prefix,omitempty"`
+	// ExpirationDays is how long current objects are kept; zero when they do not expire by age
+	ExpirationDays int `json:"expiration_days,omitempty"`
+}
+
+// TaggingConfig is GetBucketTagging; Count holds the number of tags
+type TaggingConfig struct {
+	Check
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// checkConfig runs every configuration read check on a bucket
+func (c *Checker) checkConfig(ctx context.Context, bucketName string) *BucketConfig {
+	return &BucketConfig{
+		CORS:       c.checkCORS(ctx, bucketName),
+		Website:    c.checkWebsite(ctx, bucketName),
+		Logging:    c.checkLogging(ctx, bucketName),
+		Versioning: c.checkVersioning(ctx, bucketName),
+		Encryption: c.checkEncryption(ctx, bucketName),
+		Lifecycle:  c.checkLifecycle(ctx, bucketName),
+		Tagging:    c.checkTagging(ctx, bucketName),
+	}
+}
+
+// readConfig sends a configuration Get request and parses the response into
+// v. found is false when the request failed or the bucket has no such
+// configuration, which check tells apart.
+func (c *Checker) readConfig(ctx context.Context, tag, bucketName string, op s3client.Op, v interface{}) (check Check, found bool) {
+	resp, err := c.do(ctx, &s3client.Request{Op: op, Bucket: bucketName})
+	if err == nil && !resp.OK() && s3err.Parse(resp).Category() == s3err.Absent {
+		return Check{Status: StatusOK, Detail: "not configured"}, false
+	}
+	if err != nil || !resp.OK() {
+		c.logFailure(tag, bucketName, resp, err)
+		return failedCheck(resp, err), false
+	}
+	// The CLI prints nothing for an empty document, e.g. logging turned off
+	if len(bytes.TrimSpace(resp.Body)) == 0 {
+		return ok(), true
+	}
+	if err := xml.Unmarshal(resp.Body, v); err != nil {
+		c.logFailure(tag, bucketName, nil, err)
+		return Check{Status: StatusError, Detail: fmt.Sprintf("parsing %s: %v", op, err)}, false
+	}
+	return ok(), true
+}
+
+func (c *Checker) checkCORS(ctx context.Context, bucketName string) CORSConfig {
+	var doc struct {
+		Rules []CORSRule `xml:"CORSRule"`
+	}
+	check, found := c.readConfig(ctx, "CORS", bucketName, s3client.OpGetBucketCors, &doc)
+	if !found {
+		return CORSConfig{Check: check}
+	}
+
+	var origins []string
+	seen := make(map[string]bool)
+	for _, rule := range doc.Rules {
+		for _, origin := range rule.AllowedOrigins {
+			if !seen[origin] {
+				seen[origin] = true
+				origins = append(origins, origin)
+			}
+		}
+	}
+	check.Count = len(doc.Rules)
+	check.Detail = "origins: " + strings.Join(origins, ", ")
+	if seen["*"] {
+		check.Detail = "any origin"
+	}
+	return CORSConfig{Check: check, Rules: doc.Rules}
+}
+
+func (c *Checker) checkWebsite(ctx context.Context, bucketName string) WebsiteConfig {
+	var doc struct {
+		IndexDocument string     `xml:"IndexDocument>Suffix"`
+		ErrorDocument string     `xml:"ErrorDocument>Key"`
+		RedirectTo    string     `xml:"RedirectAllRequestsTo>HostName"`
+		RoutingRules  []struct{} `xml:"RoutingRules>RoutingRule"`
+	}
+	check, found := c.readConfig(ctx, "WEBSITE", bucketName, s3client.OpGetBucketWebsite, &doc)
+	if !found {
+		return WebsiteConfig{Check: check}
+	}
+
+	check.Detail = "index " + doc.IndexDocument
+	if doc.RedirectTo != "" {
+		check.Detail = "redirects to " + doc.RedirectTo
+	}
+	return WebsiteConfig{
+		Check:         check,
+		Enabled:       true,
+		IndexDocument: doc.IndexDocument,
+		ErrorDocument: doc.ErrorDocument,
+		RedirectTo:    doc.RedirectTo,
+		RoutingRules:  len(doc.RoutingRules),
+	}
+}
+
+func (c *Checker) checkLogging(ctx context.Context, bucketName string) LoggingConfig {
+	var doc struct {
+		LoggingEnabled *struct {
+			TargetBucket string
+			TargetPrefix string
+		}
+	}
+	check, found := c.readConfig(ctx, "LOGGING", bucketName, s3client.OpGetBucketLogging, &doc)
+	if !found {
+		return LoggingConfig{Check: check}
+	}
+	// S3 answers an empty BucketLoggingStatus when logging is off
+	if doc.LoggingEnabled == nil {
+		check.Detail = "not configured"
+		return LoggingConfig{Check: check}
+	}
+
+	check.Detail = "to " + doc.LoggingEnabled.TargetBucket + "/" + doc.LoggingEnabled.TargetPrefix
+	return LoggingConfig{
+		Check:        check,
+		Enabled:      true,
+		TargetBucket: doc.LoggingEnabled.TargetBucket,
+		TargetPrefix: doc.LoggingEnabled.TargetPrefix,
+	}
+}
+
+func (c *Checker) checkVersioning(ctx context.Context, bucketName string) VersioningConfig {
+	// S3 names the element MfaDelete, the CLI's JSON MFADelete
+	var doc struct {
+		Status    VersioningStatus
+		MfaDelete string
+		MFADelete string
+	}
+	check, found := c.readConfig(ctx, "VERSIONING", bucketName, s3client.OpGetBucketVersioning, &doc)
+	if !found {
+		return VersioningConfig{Check: check}
+	}
+
+	mfaDelete := doc.MfaDelete == "Enabled" || doc.MFADelete == "Enabled"
+	switch {
+	case doc.Status == "":
+		check.Detail = "never enabled"
+	case mfaDelete:
+		check.Detail = string(doc.Status) + ", MFA delete"
+	default:
+		check.Detail = string(doc.Status)
+	}
+	return VersioningConfig{Check: check, State: doc.Status, MFADelete: mfaDelete}
+}
+
+func (c *Checker) checkEncryption(ctx context.Context, bucketName string) EncryptionConfig {
+	var doc struct {
+		Rules []struct {
+			Algorithm        string `xml:"ApplyServerSideEncryptionByDefault>SSEAlgorithm"`
+			KMSKeyID         string `xml:"ApplyServerSideEncryptionByDefault>KMSMasterKeyID"`
+			BucketKeyEnabled bool
+		} `xml:"Rule"`
+	}
+	check, found := c.readConfig(ctx, "ENCRYPTION", bucketName, s3client.OpGetBucketEncryption, &doc)
+	if !found {
+		return EncryptionConfig{Check: check}
+	}
+	if len(doc.Rules) == 0 {
+		check.Detail = "not configured"
+		return EncryptionConfig{Check: check}
+	}
+
+	// A bucket has a single default encryption rule
+	rule := doc.Rules[0]
+	check.Detail = rule.Algorithm
+	if rule.KMSKeyID != "" {
+		check.Detail += " " + rule.KMSKeyID
+	}
+	return EncryptionConfig{
+		Check:            check,
+		Algorithm:        rule.Algorithm,
+		KMSKeyID:         rule.KMSKeyID,
+		BucketKeyEnabled: rule.BucketKeyEnabled,
+	}
+}
+
+func (c *Checker) checkLifecycle(ctx context.Context, bucketName string) LifecycleConfig {
+	// Prefix is either directly in the rule (the original schema) or in its Filter
+	var doc struct {
+		Rules []struct {
+			ID             string
+			Status         string
+			Prefix         string
+			FilterPrefix   string `xml:"Filter>Prefix"`
+			AndPrefix      string `xml:"Filter>And>Prefix"`
+			ExpirationDays int    `xml:"Expiration>Days"`
+		} `xml:"Rule"`
+	}
+	check, found := c.readConfig(ctx, "LIFECYCLE", bucketName, s3client.OpGetBucketLifecycle, &doc)
+	if !found {
+		return LifecycleConfig{Check: check}
+	}
+
+	rules := make([]LifecycleRule, 0, len(doc.Rules))
+	enabled := 0
+	for _, r := range doc.Rules {
+		rule := LifecycleRule{ID: r.ID, Enabled: r.Status == "Enabled", Prefix: r.Prefix, ExpirationDays: r.ExpirationDays}
+		for _, prefix := range []string{r.FilterPrefix, r.AndPrefix} {
+			if rule.Prefix == "" {
+				rule.Prefix = prefix
+			}
+		}
+		if rule.Enabled {
+			enabled++
+		}
+		rules = append(rules, rule)
+	}
+	check.Count = len(rules)
+	check.Detail = fmt.Sprintf("%d of %d rules enabled", enabled, len(rules))
+	return LifecycleConfig{Check: check, Rules: rules}
+}
+
+func (c *Checker) checkTagging(ctx context.Context, bucketName string) TaggingConfig {
+	var doc struct {
+		Tags []struct {
+			Key   string
+			Value string
+		} `xml:"TagSet>Tag"`
+	}
+	check, found := c.readConfig(ctx, "TAGGING", bucketName, s3client.OpGetBucketTagging, &doc)
+	if !found {
+		return TaggingConfig{Check: check}
+	}
+
+	tags := make(map[string]string, len(doc.Tags))
+	keys := make([]string, 0, len(doc.Tags))
+	for _, tag := range doc.Tags {
+		tags[tag.Key] = tag.Value
+		keys = append(keys, tag.Key)
+	}
+	check.Count = len(tags)
+	check.Detail = "keys: " + strings.Join(keys, ", ")
+	return TaggingConfig{Check: check, Tags: tags}
+}
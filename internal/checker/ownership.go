@@ -0,0 +1,62 @@
+package checker
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
+)
+
+// ObjectOwnership is a bucket's Object Ownership setting
+type ObjectOwnership string
+
+const (
+	// OwnershipEnforced disables ACLs; the bucket owner owns every object
+	OwnershipEnforced ObjectOwnership = "BucketOwnerEnforced"
+	// OwnershipPreferred keeps ACLs; the bucket owner owns objects uploaded
+	// with the bucket-owner-full-control canned ACL
+	OwnershipPreferred ObjectOwnership = "BucketOwnerPreferred"
+	// OwnershipObjectWriter keeps ACLs; the uploading account owns each object.
+	// It also applies to buckets without ownership controls.
+	OwnershipObjectWriter ObjectOwnership = "ObjectWriter"
+)
+
+// ACLsDisabled reports whether ACLs have no effect on the bucket
+func (o ObjectOwnership) ACLsDisabled() bool {
+	return o == OwnershipEnforced
+}
+
+// checkOwnership reads the bucket's Object Ownership setting. The mode is
+// empty when it could not be read.
+func (c *Checker) checkOwnership(ctx context.Context, bucketName string) (Check, ObjectOwnership) {
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpGetBucketOwnershipControls, Bucket: bucketName})
+	if err == nil && !resp.OK() && s3err.Parse(resp).Category() == s3err.Absent {
+		return Check{Status: StatusOK, Detail: "no ownership controls, ACLs enabled"}, OwnershipObjectWriter
+	}
+	if err != nil || !resp.OK() {
+		c.logFailure("OWNERSHIP", bucketName, resp, err)
+		return failedCheck(resp, err), ""
+	}
+
+	var controls struct {
+		Rules []struct {
+			ObjectOwnership ObjectOwnership
+		} `xml:"Rule"`
+	}
+	if err := xml.Unmarshal(resp.Body, &controls); err != nil || len(controls.Rules) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no ownership rule")
+		}
+		c.logFailure("OWNERSHIP", bucketName, nil, err)
+		return Check{Status: StatusError, Detail: fmt.Sprintf("parsing ownership controls: %v", err)}, ""
+	}
+	mode := controls.Rules[0].ObjectOwnership
+	return Check{Status: StatusOK, Detail: string(mode)}, mode
+}
+
+// aclsDisabled is the result of an ACL check on a bucket with ACLs disabled
+func aclsDisabled() Check {
+	return Check{Status: StatusNA, Detail: "ACLs disabled (BucketOwnerEnforced)"}
+}
@@ -0,0 +1,209 @@
+package checker
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
+)
+
+// PublicAccessFlags are the four Block Public Access settings
+type PublicAccessFlags struct {
+	// BlockPublicAcls rejects requests that would add a public ACL
+	BlockPublicAcls bool `json:"block_public_acls"`
+	// IgnorePublicAcls makes existing public ACLs on the bucket and its objects ineffective
+	IgnorePublicAcls bool `json:"ignore_public_acls"`
+	// BlockPublicPolicy rejects bucket policies that would grant public access
+	BlockPublicPolicy bool `json:"block_public_policy"`
+	// RestrictPublicBuckets limits a public bucket policy to AWS services and the owner's account
+	RestrictPublicBuckets bool `json:"restrict_public_buckets"`
+}
+
+func (f PublicAccessFlags) union(other PublicAccessFlags) PublicAccessFlags {
+	return PublicAccessFlags{
+		BlockPublicAcls:       f.BlockPublicAcls || other.BlockPublicAcls,
+		IgnorePublicAcls:      f.IgnorePublicAcls || other.IgnorePublicAcls,
+		BlockPublicPolicy:     f.BlockPublicPolicy || other.BlockPublicPolicy,
+		RestrictPublicBuckets: f.RestrictPublicBuckets || other.RestrictPublicBuckets,
+	}
+}
+
+// Names lists the flags that are set
+func (f PublicAccessFlags) Names() []string {
+	var names []string
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"BlockPublicAcls", f.BlockPublicAcls},
+		{"IgnorePublicAcls", f.IgnorePublicAcls},
+		{"BlockPublicPolicy", f.BlockPublicPolicy},
+		{"RestrictPublicBuckets", f.RestrictPublicBuckets},
+	} {
+		if flag.set {
+			names = append(names, flag.name)
+		}
+	}
+	return names
+}
+
+// BlockPublicAccess is the Block Public Access configuration that applies to
+// a bucket: its own settings and those of the account that owns it
+type BlockPublicAccess struct {
+	// Bucket and Account are the configured flags; nil when they could not be
+	// read, all false when nothing is configured
+	Bucket  *PublicAccessFlags `json:"bucket,omitempty"`
+	Account *PublicAccessFlags `json:"account,omitempty"`
+	// AccountCheck is the S3 Control read of the account-level settings. It is
+	// SKIPPED unless the bucket is known to be in the caller's account.
+	AccountCheck Check `json:"account_check"`
+	// Effective combines both levels; a flag set at either level applies
+	Effective PublicAccessFlags `json:"effective"`
+}
+
+// ACLsIgnored reports whether grants to AllUsers and AuthenticatedUsers in
+// ACLs have no effect
+func (b BlockPublicAccess) ACLsIgnored() bool {
+	return b.Effective.IgnorePublicAcls
+}
+
+// PolicyRestricted reports whether public grants in the bucket policy have no
+// effect for anonymous and cross-account callers
+func (b BlockPublicAccess) PolicyRestricted() bool {
+	return b.Effective.RestrictPublicBuckets
+}
+
+// explain notes on a denied anonymous probe when Block Public Access rules
+// out public access through both ACLs and the bucket policy
+func (b BlockPublicAccess) explain(check Check) Check {
+	if check.Status == StatusDenied && b.ACLsIgnored() && b.PolicyRestricted() {
+		check.Detail = "blocked by public access block"
+	}
+	return check
+}
+
+// accountInfo is the caller's account ID and its account-level Block Public
+// Access settings, looked up once per run
+type accountInfo struct {
+	once  sync.Once
+	id    string
+	check Check
+	flags *PublicAccessFlags
+}
+
+// resolveAccount finds the caller's account ID, from SetAccountID or STS
+// GetCallerIdentity, and reads the account-level Block Public Access settings
+func (c *Checker) resolveAccount(ctx context.Context) *accountInfo {
+	c.account.once.Do(func() {
+		info := &c.account
+		info.id = c.accountID
+		if info.id == "" {
+			info.id = c.callerAccount(ctx)
+		}
+		if info.id == "" {
+			info.check = skipped("account ID unknown")
+			return
+		}
+
+		resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpGetAccountPublicAccessBlock, AccountID: info.id})
+		info.check, info.flags = publicAccessFlags(resp, err)
+		if info.check.Status != StatusOK {
+			c.logFailure("ACCOUNT-PAB", info.id, resp, err)
+		}
+	})
+	return &c.account
+}
+
+// callerAccount asks STS for the account of the credentials in use
+func (c *Checker) callerAccount(ctx context.Context) string {
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpGetCallerIdentity})
+	if err != nil || !resp.OK() {
+		c.logFailure("ACCOUNT", "GetCallerIdentity", resp, err)
+		return ""
+	}
+	var identity struct {
+		Account string `xml:"GetCallerIdentityResult>Account"`
+	}
+	if err := xml.Unmarshal(resp.Body, &identity); err != nil {
+		c.logFailure("ACCOUNT", "GetCallerIdentity", nil, err)
+		return ""
+	}
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "[ACCOUNT] checking as account %s\n", identity.Account)
+	}
+	return identity.Account
+}
+
+// checkPublicAccessBlock reads the bucket's Block Public Access settings and
+// combines them with the account's when the bucket is confirmed to belong to
+// the caller's account
+func (c *Checker) checkPublicAccessBlock(ctx context.Context, bucketName string) (Check, BlockPublicAccess) {
+	account := c.resolveAccount(ctx)
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpGetPublicAccessBlock, Bucket: bucketName})
+	check, flags := publicAccessFlags(resp, err)
+	if check.Status != StatusOK {
+		c.logFailure("PAB", bucketName, resp, err)
+	}
+
+	bpa := BlockPublicAccess{Bucket: flags, AccountCheck: account.check}
+	switch {
+	case account.flags == nil:
+		// AccountCheck says why the account-level settings are unknown
+	case flags != nil && c.ownedBy(ctx, bucketName, account.id):
+		bpa.Account = account.flags
+	default:
+		bpa.AccountCheck = skipped("bucket not confirmed to be in account " + account.id)
+	}
+	for _, level := range []*PublicAccessFlags{bpa.Bucket, bpa.Account} {
+		if level != nil {
+			bpa.Effective = bpa.Effective.union(*level)
+		}
+	}
+
+	if check.Status == StatusOK {
+		names := bpa.Effective.Names()
+		check.Count = len(names)
+		check.Detail = "no flags set"
+		if len(names) > 0 {
+			check.Detail = strings.Join(names, ", ")
+		}
+	}
+	return check, bpa
+}
+
+// ownedBy confirms that accountID owns the bucket by reading its Block Public
+// Access settings again with accountID as the expected owner, which S3
+// refuses with 403 for a bucket of any other account
+func (c *Checker) ownedBy(ctx context.Context, bucketName, accountID string) bool {
+	resp, err := c.do(ctx, &s3client.Request{
+		Op:                  s3client.OpGetPublicAccessBlock,
+		Bucket:              bucketName,
+		ExpectedBucketOwner: accountID,
+	})
+	check, _ := publicAccessFlags(resp, err)
+	if check.Status != StatusOK {
+		c.logFailure("PAB-OWNER", bucketName, resp, err)
+	}
+	return check.Status == StatusOK
+}
+
+// publicAccessFlags reads a GetPublicAccessBlock response of either level. A
+// missing configuration means no flag is set.
+func publicAccessFlags(resp *s3client.Response, err error) (Check, *PublicAccessFlags) {
+	if err == nil && !resp.OK() && s3err.Parse(resp).Category() == s3err.Absent {
+		return ok(), &PublicAccessFlags{}
+	}
+	if err != nil || !resp.OK() {
+		return failedCheck(resp, err), nil
+	}
+	var flags PublicAccessFlags
+	if err := xml.Unmarshal(resp.Body, &flags); err != nil {
+		return Check{Status: StatusError, Detail: fmt.Sprintf("parsing public access block: %v", err)}, nil
+	}
+	return ok(), &flags
+}
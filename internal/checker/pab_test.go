@@ -0,0 +1,56 @@
+package checker
+
+import (
+	"net/http"
+	"testing"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3fake"
+)
+
+// bucketPAB answers GetPublicAccessBlock with flags, refusing requests that
+// expect any owner but owner, as S3 does
+func bucketPAB(owner, flags string) s3fake.Handler {
+	return func(req *s3client.Request) (*s3client.Response, error) {
+		if req.ExpectedBucketOwner != "" && req.ExpectedBucketOwner != owner {
+			return s3fake.Error(http.StatusForbidden, "AccessDenied"), nil
+		}
+		return s3fake.OK(flags), nil
+	}
+}
+
+func TestPublicAccessBlockLevels(t *testing.T) {
+	const bucketFlags = `<PublicAccessBlockConfiguration><BlockPublicAcls>true</BlockPublicAcls></PublicAccessBlockConfiguration>`
+	const accountFlags = `<PublicAccessBlockConfiguration><RestrictPublicBuckets>true</RestrictPublicBuckets></PublicAccessBlockConfiguration>`
+
+	tests := []struct {
+		name        string
+		owner       string
+		wantAccount bool
+		wantCount   int
+	}{
+		{"own bucket", testAccount, true, 2},
+		{"other account's bucket", "444455556666", false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := s3fake.New().
+				Handle("b", s3client.OpHeadBucket, s3fake.Respond(s3fake.Status(http.StatusOK))).
+				Handle("b", s3client.OpGetPublicAccessBlock, bucketPAB(tt.owner, bucketFlags)).
+				Handle("", s3client.OpGetAccountPublicAccessBlock, s3fake.Respond(s3fake.OK(accountFlags)))
+			result := checkFake(t, f, "b")
+			bpa := result.BlockPublicAccess
+
+			// The bucket's own flags are read whoever owns it
+			if result.PublicAccessBlock.Status != StatusOK || bpa.Bucket == nil || !bpa.Bucket.BlockPublicAcls {
+				t.Fatalf("bucket flags not read: %+v %+v", result.PublicAccessBlock, bpa.Bucket)
+			}
+			if got := bpa.Account != nil; got != tt.wantAccount {
+				t.Errorf("account flags applied = %v, want %v (%s)", got, tt.wantAccount, bpa.AccountCheck.Detail)
+			}
+			if result.PublicAccessBlock.Count != tt.wantCount {
+				t.Errorf("count = %d, want %d", result.PublicAccessBlock.Count, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"s3-check/internal/policy"
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
+)
+
+// SetAccountID sets the caller's account ID instead of asking STS. Policy
+// grants to it are not reported, and its account-level Block Public Access
+// settings apply to the buckets it owns.
+func (c *Checker) SetAccountID(accountID string) {
+	c.accountID = accountID
+}
+
+// checkPolicy reads the bucket policy and lists the grants it makes to
+// anyone but the caller's account
+func (c *Checker) checkPolicy(ctx context.Context, bucketName string) (Check, []policy.Grant) {
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpGetBucketPolicy, Bucket: bucketName})
+	if err == nil && !resp.OK() && s3err.Parse(resp).Code == "NoSuchBucketPolicy" {
+		return Check{Status: StatusOK, Detail: "no bucket policy"}, nil
+	}
+	if err != nil || !resp.OK() {
+		c.logFailure("GET-POLICY", bucketName, resp, err)
+		return failedCheck(resp, err), nil
+	}
+
+	doc, err := policy.Parse(resp.Body)
+	if err != nil {
+		c.logFailure("GET-POLICY", bucketName, nil, err)
+		return Check{Status: StatusError, Detail: err.Error()}, nil
+	}
+	grants := policy.Analyze(doc, c.resolveAccount(ctx).id)
+	if c.verbose {
+		for _, grant := range grants {
+			fmt.Fprintf(os.Stderr, "[GET-POLICY] %s: %s\n", bucketName, grant)
+		}
+	}
+	return Check{Status: StatusOK, Detail: grantSummary(grants), Count: len(grants)}, grants
+}
+
+// grantSummary counts grants per audience, e.g. "grants: 1 public, 2 account"
+func grantSummary(grants []policy.Grant) string {
+	if len(grants) == 0 {
+		return "no grants to other accounts"
+	}
+	counts := make(map[policy.Audience]int)
+	for _, grant := range grants {
+		counts[grant.Audience]++
+	}
+	var parts []string
+	for _, audience := range []policy.Audience{policy.Public, policy.Authenticated, policy.Account} {
+		if counts[audience] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[audience], audience))
+		}
+	}
+	return "grants: " + strings.Join(parts, ", ")
+}
+
+// anonGetFromPolicy decides ANON-GET from the bucket policy when the anonymous
+// probe was inconclusive or denied
+func anonGetFromPolicy(bucketName string, bpa BlockPublicAccess, policyCheck Check, grants []policy.Grant) Check {
+	if policyCheck.Status != StatusOK {
+		return setupFailed(policyCheck, "anonymous probe inconclusive and bucket policy unreadable")
+	}
+	grant, found := policy.Find(grants, policy.Public, "s3:GetObject", "arn:aws:s3:::"+bucketName+"/*")
+	switch {
+	case !found:
+		return Check{Status: StatusDenied, Detail: "bucket policy grants no public read"}
+	case bpa.PolicyRestricted():
+		return Check{Status: StatusDenied, Detail: "public read in bucket policy restricted by RestrictPublicBuckets"}
+	case grant.Narrowed:
+		return Check{Status: StatusUnknown, Detail: "bucket policy grants public read narrowed by " + strings.Join(grant.NarrowedBy(), ", ")}
+	}
+	return Check{Status: StatusOK, Detail: "bucket policy grants public read"}
+}
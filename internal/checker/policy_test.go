@@ -0,0 +1,44 @@
+package checker
+
+import (
+	"net/http"
+	"testing"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3fake"
+)
+
+func TestAnonGetFromPolicyWhenProbeDenied(t *testing.T) {
+	const publicRead = `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::pub/*"}]}`
+	const narrowedRead = `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::pub/*",
+		"Condition":{"IpAddress":{"aws:SourceIp":"203.0.113.0/24"}}}]}`
+	const restricted = `<PublicAccessBlockConfiguration><RestrictPublicBuckets>true</RestrictPublicBuckets></PublicAccessBlockConfiguration>`
+
+	tests := []struct {
+		name   string
+		policy *s3client.Response
+		pab    *s3client.Response
+		want   Status
+	}{
+		{"public read", s3fake.OK(publicRead), nil, StatusOK},
+		{"narrowed public read", s3fake.OK(narrowedRead), nil, StatusUnknown},
+		{"restricted by RestrictPublicBuckets", s3fake.OK(publicRead), s3fake.OK(restricted), StatusDenied},
+		{"no bucket policy", s3fake.Error(http.StatusNotFound, "NoSuchBucketPolicy"), nil, StatusDenied},
+		{"unreadable policy", s3fake.Error(http.StatusForbidden, "AccessDenied"), nil, StatusDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without s3:ListBucket the HEAD on a missing key is refused with 403
+			f := s3fake.New().
+				Handle("pub", s3client.OpGetBucketPolicy, s3fake.Respond(tt.policy)).
+				HandleAnonymous("pub", s3client.OpHeadObject, s3fake.Respond(s3fake.Status(http.StatusForbidden)))
+			if tt.pab != nil {
+				f.Handle("pub", s3client.OpGetPublicAccessBlock, s3fake.Respond(tt.pab))
+			}
+			result := checkFake(t, f, "pub")
+			if result.AnonGet.Status != tt.want {
+				t.Errorf("ANON-GET = %s (%s), want %s", result.AnonGet.Status, result.AnonGet.Detail, tt.want)
+			}
+		})
+	}
+}
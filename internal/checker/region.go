@@ -0,0 +1,184 @@
+package checker
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"sync"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
+)
+
+// regionCache remembers the region of every bucket seen so far
+type regionCache struct {
+	mu      sync.Mutex
+	regions map[string]string
+}
+
+func (r *regionCache) get(bucketName string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	region, ok := r.regions[bucketName]
+	return region, ok
+}
+
+func (r *regionCache) set(bucketName, region string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.regions == nil {
+		r.regions = make(map[string]string)
+	}
+	r.regions[bucketName] = region
+}
+
+// SetRegionHint seeds the region of a bucket, e.g. from the endpoint URL it was
+// referenced by. A wrong hint is corrected by the first redirect S3 returns.
+func (c *Checker) SetRegionHint(bucketName, region string) {
+	c.regions.set(bucketName, region)
+}
+
+// preflightCache keeps the HEAD bucket verdicts of ProbeBuckets until the
+// full checks of the same bucket pick them up
+type preflightCache struct {
+	mu      sync.Mutex
+	results map[string]preflight
+}
+
+type preflight struct {
+	check     Check
+	existence Existence
+}
+
+func (p *preflightCache) put(bucketName string, check Check, existence Existence) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.results == nil {
+		p.results = make(map[string]preflight)
+	}
+	p.results[bucketName] = preflight{check, existence}
+}
+
+// take returns and forgets the verdict for bucketName, so it is used once
+func (p *preflightCache) take(bucketName string) (preflight, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.results[bucketName]
+	delete(p.results, bucketName)
+	return result, ok
+}
+
+// headBucket returns the pre-flight verdict ProbeBuckets already reached for
+// bucketName, or runs the pre-flight
+func (c *Checker) headBucket(ctx context.Context, bucketName string) (Check, Existence) {
+	if probed, ok := c.preflights.take(bucketName); ok {
+		return probed.check, probed.existence
+	}
+	return c.checkHeadBucket(ctx, bucketName)
+}
+
+// do sends req to the bucket's region. If S3 still answers with a redirect
+// naming another region, the cache is corrected and the request retried once.
+func (c *Checker) do(ctx context.Context, req *s3client.Request) (*s3client.Response, error) {
+	if req.Bucket != "" && req.Region == "" {
+		req.Region, _ = c.regions.get(req.Bucket)
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil || resp.OK() || req.Bucket == "" {
+		return resp, err
+	}
+
+	apiErr := s3err.Parse(resp)
+	if apiErr.Category() != s3err.Redirect || apiErr.Region == "" || apiErr.Region == req.Region {
+		return resp, err
+	}
+	c.regions.set(req.Bucket, apiErr.Region)
+	retry := *req
+	retry.Region = apiErr.Region
+	return c.send(ctx, &retry)
+}
+
+// checkHeadBucket runs the HEAD bucket pre-flight. It falls back to an
+// anonymous request when the signed one cannot be sent (e.g. no credentials).
+func (c *Checker) checkHeadBucket(ctx context.Context, bucketName string) (Check, Existence) {
+	resp, err := c.do(ctx, &s3client.Request{Op: s3client.OpHeadBucket, Bucket: bucketName})
+	if err != nil {
+		c.logFailure("HEAD", bucketName, resp, err)
+		resp, err = c.do(ctx, &s3client.Request{Op: s3client.OpHeadBucket, Bucket: bucketName, Anonymous: true})
+	}
+	if err != nil {
+		c.logFailure("HEAD", bucketName+" (anonymous)", resp, err)
+		return failedCheck(resp, err), ExistenceUnknown
+	}
+	if region := resp.Header.Get("X-Amz-Bucket-Region"); region != "" {
+		c.regions.set(bucketName, region)
+	}
+	if resp.OK() {
+		return ok(), ExistenceExists
+	}
+
+	c.logFailure("HEAD", bucketName, resp, nil)
+	check := failedCheck(resp, nil)
+	switch s3err.Parse(resp).Category() {
+	case s3err.Denied:
+		return check, ExistenceOwnedByOther
+	case s3err.Absent, s3err.BucketNotFound:
+		// HEAD responses have no body, so a missing bucket is a bare 404
+		check.Status = StatusNotFound
+		return check, ExistenceNotFound
+	case s3err.Redirect:
+		return check, ExistenceRedirect
+	}
+	return check, ExistenceUnknown
+}
+
+// ResolveRegion discovers and caches the region of a bucket. It reads the
+// x-amz-bucket-region header S3 returns on HEAD bucket (including 301 and 403
+// responses), trying a signed then an anonymous request, and falls back to
+// GetBucketLocation. An empty string means the region could not be determined.
+func (c *Checker) ResolveRegion(ctx context.Context, bucketName string) string {
+	if region, ok := c.regions.get(bucketName); ok {
+		return region
+	}
+
+	for _, anonymous := range []bool{false, true} {
+		resp, err := c.send(ctx, &s3client.Request{
+			Op:        s3client.OpHeadBucket,
+			Bucket:    bucketName,
+			Anonymous: anonymous,
+		})
+		if err != nil {
+			continue
+		}
+		if region := resp.Header.Get("X-Amz-Bucket-Region"); region != "" {
+			c.regions.set(bucketName, region)
+			return region
+		}
+	}
+
+	resp, err := c.send(ctx, &s3client.Request{Op: s3client.OpGetBucketLocation, Bucket: bucketName})
+	if err != nil || !resp.OK() {
+		c.logFailure("REGION", bucketName, resp, err)
+		return ""
+	}
+	var location string
+	if err := xml.Unmarshal(resp.Body, &location); err != nil {
+		c.logFailure("REGION", bucketName, nil, err)
+		return ""
+	}
+	region := normalizeLocation(location)
+	c.regions.set(bucketName, region)
+	return region
+}
+
+// normalizeLocation maps GetBucketLocation's legacy constraint values to region names
+func normalizeLocation(location string) string {
+	switch strings.TrimSpace(location) {
+	case "":
+		return "us-east-1"
+	case "EU":
+		return "eu-west-1"
+	}
+	return strings.TrimSpace(location)
+}
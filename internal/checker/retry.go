@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"s3-check/internal/ratelimit"
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
+)
+
+const (
+	// DefaultRPS is the default global request rate (requests per second)
+	DefaultRPS = 50
+	// DefaultMaxRetries is how often a throttled request is retried by default
+	DefaultMaxRetries = 3
+	// DefaultOpTimeout bounds a single request attempt
+	DefaultOpTimeout = 30 * time.Second
+)
+
+// SetRateLimit limits requests per second overall and per bucket; zero or
+// less disables the respective limit
+func (c *Checker) SetRateLimit(rps, bucketRPS float64) {
+	c.limiter = ratelimit.New(rps, burstFor(rps))
+	c.bucketLimiters = ratelimit.NewKeyed(bucketRPS, burstFor(bucketRPS))
+}
+
+// SetMaxRetries sets how often a throttled request (SlowDown, 503, RequestTimeout) is retried
+func (c *Checker) SetMaxRetries(n int) {
+	if n < 0 {
+		n = 0
+	}
+	c.maxRetries = n
+}
+
+// burstFor allows roughly one second's worth of requests at once
+func burstFor(rps float64) int {
+	return int(math.Max(1, math.Ceil(rps)))
+}
+
+// send passes req to the transport once the rate limiters allow it, and
+// retries with exponential backoff and jitter while S3 reports throttling.
+// Every request the checker makes goes through here.
+func (c *Checker) send(ctx context.Context, req *s3client.Request) (*s3client.Response, error) {
+	bucketLimiter := c.bucketLimiters.Get(req.Bucket)
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := bucketLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.attempt(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.OK() || s3err.Parse(resp).Category() != s3err.Throttled {
+			c.limiter.Recover()
+			bucketLimiter.Recover()
+			return resp, nil
+		}
+		if attempt >= c.maxRetries {
+			return resp, nil
+		}
+
+		// Slow everyone down, then wait before trying again
+		c.limiter.Throttle()
+		bucketLimiter.Throttle()
+		delay := ratelimit.Backoff(attempt)
+		c.logFailure("RETRY", fmt.Sprintf("%s %s (attempt %d, waiting %s)", req.Op, req.Bucket, attempt+1, delay), resp, nil)
+		if err := ratelimit.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends req once, bounded by the per-request timeout
+func (c *Checker) attempt(ctx context.Context, req *s3client.Request) (*s3client.Response, error) {
+	if c.opTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opTimeout)
+		defer cancel()
+	}
+	return c.transport.Do(ctx, req)
+}
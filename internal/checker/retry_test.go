@@ -0,0 +1,54 @@
+package checker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3fake"
+)
+
+// answers returns a Handler replying with each response in turn, repeating the last
+func answers(responses ...*s3client.Response) s3fake.Handler {
+	calls := 0
+	return func(*s3client.Request) (*s3client.Response, error) {
+		resp := responses[min(calls, len(responses)-1)]
+		calls++
+		return resp, nil
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	const maxRetries = 2
+	tests := []struct {
+		name      string
+		responses []*s3client.Response
+		calls     int
+		status    Status
+	}{
+		{"SlowDown", []*s3client.Response{s3fake.Error(http.StatusServiceUnavailable, "SlowDown")}, maxRetries + 1, StatusError},
+		{"bodyless 503", []*s3client.Response{s3fake.Status(http.StatusServiceUnavailable)}, maxRetries + 1, StatusError},
+		{"AccessDenied", []*s3client.Response{s3fake.Error(http.StatusForbidden, "AccessDenied")}, 1, StatusDenied},
+		{"recovers", []*s3client.Response{s3fake.Error(http.StatusServiceUnavailable, "SlowDown"), s3fake.Status(http.StatusNotFound)}, 2, StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := s3fake.New().Handle("b", s3client.OpHeadObject, answers(tt.responses...))
+			c, err := NewChecker(WithTransport(f))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.SetRateLimit(0, 0)
+			c.SetMaxRetries(maxRetries)
+
+			check := c.checkAuthGet(context.Background(), "b")
+			if check.Status != tt.status {
+				t.Errorf("status = %s (%s), want %s", check.Status, check.Detail, tt.status)
+			}
+			if calls := len(f.Calls()); calls != tt.calls {
+				t.Errorf("sent %d requests, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package checker
+
+// Severity ranks how much a bucket's permissions expose it
+type Severity string
+
+const (
+	// SeverityNone means no risky permission was found
+	SeverityNone Severity = "NONE"
+	// SeverityLow means a service or group has access worth reviewing
+	SeverityLow Severity = "LOW"
+	// SeverityMedium means data can be read or enumerated by anyone
+	SeverityMedium Severity = "MEDIUM"
+	// SeverityHigh means access controls can be changed
+	SeverityHigh Severity = "HIGH"
+	// SeverityCritical means anyone can change or destroy data
+	SeverityCritical Severity = "CRITICAL"
+)
+
+// Severities lists the severities a finding can have, most severe first
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+
+// Rank orders severities; a higher rank is more severe
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+// Finding is a risky permission that was allowed
+type Finding struct {
+	// Check names the rule, e.g. ANON-WRITE for the column of that name or
+	// ACL-PUBLIC-READ for a grant in the bucket ACL
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Summary  string   `json:"summary"`
+}
+
+// RiskRule raises a finding when raised holds for a result
+type RiskRule struct {
+	Check    string
+	Severity Severity
+	Summary  string
+	raised   func(BucketResult) bool
+}
+
+// allowed raises a finding when a check comes back OK
+func allowed(check func(BucketResult) Check) func(BucketResult) bool {
+	return func(r BucketResult) bool { return check(r).Status == StatusOK }
+}
+
+// RiskRules are the checks a bucket is scored on, most severe first
+var RiskRules = []RiskRule{
+	{Check: "ANON-WRITE", Severity: SeverityCritical, Summary: "Bucket allows anonymous writes", raised: allowed(func(r BucketResult) Check { return r.AnonWrite })},
+	{Check: "ANON-DEL", Severity: SeverityCritical, Summary: "Bucket allows anonymous deletes", raised: allowed(func(r BucketResult) Check { return r.AnonDel })},
+	{Check: "ACL-PUBLIC-WRITE", Severity: SeverityCritical, Summary: "Bucket ACL grants write or ACL access to all users or any AWS account", raised: aclGranted(publicGroup, writePermissions)},
+	{Check: "PUT-ACL", Severity: SeverityHigh, Summary: "Bucket ACL can be modified", raised: allowed(func(r BucketResult) Check { return r.PutACL })},
+	{Check: "ACL-CROSS-ACCOUNT", Severity: SeverityHigh, Summary: "Bucket ACL grants write or ACL access to another account", raised: aclGranted(otherAccount, writePermissions)},
+	{Check: "ANON-GET", Severity: SeverityMedium, Summary: "Bucket objects are publicly readable", raised: allowed(func(r BucketResult) Check { return r.AnonGet })},
+	{Check: "ANON-LIST", Severity: SeverityMedium, Summary: "Bucket contents can be listed anonymously", raised: allowed(func(r BucketResult) Check { return r.AnonList })},
+	{Check: "ACL-PUBLIC-READ", Severity: SeverityMedium, Summary: "Bucket ACL grants read access to all users or any AWS account", raised: aclGranted(publicGroup, readPermissions)},
+	{Check: "ACL-LOG-DELIVERY", Severity: SeverityLow, Summary: "Bucket ACL grants access to the S3 log delivery group", raised: aclGranted(logDelivery, allPermissions)},
+}
+
+// score sets Findings and Risk from the completed checks
+func (r *BucketResult) score() {
+	r.Findings = nil
+	r.Risk = SeverityNone
+	for _, rule := range RiskRules {
+		if !rule.raised(*r) {
+			continue
+		}
+		r.Findings = append(r.Findings, Finding{Check: rule.Check, Severity: rule.Severity, Summary: rule.Summary})
+		if rule.Severity.Rank() > r.Risk.Rank() {
+			r.Risk = rule.Severity
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package checker
+
+import (
+	"s3-check/internal/s3client"
+	"s3-check/internal/s3err"
+)
+
+// Status is the outcome of a single permission check
+type Status string
+
+const (
+	// StatusOK means the operation was allowed
+	StatusOK Status = "OK"
+	// StatusDenied means S3 refused the operation (AccessDenied and friends)
+	StatusDenied Status = "DENIED"
+	// StatusNotFound means the bucket does not exist
+	StatusNotFound Status = "NOT_FOUND"
+	// StatusError means the check could not be completed (network, credentials, throttling)
+	StatusError Status = "ERROR"
+	// StatusSkipped means the check was not attempted
+	StatusSkipped Status = "SKIPPED"
+	// StatusUnknown means S3 answered but the answer does not settle the question
+	StatusUnknown Status = "UNKNOWN"
+	// StatusNA means the check does not apply to the bucket, e.g. ACL checks
+	// when Object Ownership disables ACLs
+	StatusNA Status = "N/A"
+)
+
+// Check is the result of one permission check, with the S3 error behind
+// any non-OK status
+type Check struct {
+	Status Status `json:"status"`
+	// ErrorCode is the S3 error code, e.g. AccessDenied or NoSuchBucket
+	ErrorCode string `json:"error_code,omitempty"`
+	// HTTPStatus is the status code of the deciding response (0 if none was received)
+	HTTPStatus int `json:"http_status,omitempty"`
+	// RequestID and HostID identify the request to AWS support
+	RequestID string `json:"request_id,omitempty"`
+	HostID    string `json:"host_id,omitempty"`
+	// Detail is a short human readable explanation
+	Detail string `json:"detail,omitempty"`
+	// Count is the number of items observed, e.g. keys on the first page of a listing
+	Count int `json:"count"`
+}
+
+func (c Check) String() string {
+	return string(c.Status)
+}
+
+func ok() Check {
+	return Check{Status: StatusOK}
+}
+
+// failedCheck classifies a failed request. err is a transport error; resp is
+// the non-2xx response when one was received.
+func failedCheck(resp *s3client.Response, err error) Check {
+	if err != nil {
+		return Check{Status: StatusError, Detail: err.Error()}
+	}
+
+	apiErr := s3err.Parse(resp)
+	check := Check{
+		ErrorCode:  apiErr.Code,
+		HTTPStatus: apiErr.StatusCode,
+		RequestID:  apiErr.RequestID,
+		HostID:     apiErr.HostID,
+		Detail:     apiErr.Message,
+	}
+	switch apiErr.Category() {
+	case s3err.Denied:
+		check.Status = StatusDenied
+	case s3err.BucketNotFound:
+		check.Status = StatusNotFound
+	case s3err.Redirect, s3err.Absent:
+		// A redirect means we never got an answer; an absent object or
+		// configuration says nothing about the permission itself
+		check.Status = StatusUnknown
+	default: // Credentials, Throttled, Other
+		check.Status = StatusError
+	}
+	return check
+}
+
+// setupFailed is used when a prerequisite step failed, so the permission
+// itself was never exercised. A missing bucket is still reported as such.
+func setupFailed(check Check, detail string) Check {
+	if check.Status == StatusNotFound || check.Status == StatusError {
+		return check
+	}
+	check.Status = StatusUnknown
+	check.Detail = detail
+	return check
+}
+
+// Existence is what a HEAD bucket pre-flight says about a bucket
+type Existence string
+
+const (
+	// ExistenceExists means HEAD bucket succeeded
+	ExistenceExists Existence = "EXISTS"
+	// ExistenceOwnedByOther means the bucket exists but HEAD bucket was refused
+	ExistenceOwnedByOther Existence = "OWNED_BY_OTHER"
+	// ExistenceNotFound means the bucket does not exist
+	ExistenceNotFound Existence = "NOT_FOUND"
+	// ExistenceRedirect means the bucket exists behind another endpoint we could not follow
+	ExistenceRedirect Existence = "REDIRECT"
+	// ExistenceUnknown means the pre-flight failed (network, credentials)
+	ExistenceUnknown Existence = "UNKNOWN"
+)
+
+func skipped(detail string) Check {
+	return Check{Status: StatusSkipped, Detail: detail}
+}
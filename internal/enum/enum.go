@@ -0,0 +1,118 @@
+// Package enum generates candidate bucket names for an organisation from a
+// few keywords, for discovering buckets that are not in any inventory.
+package enum
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"strings"
+
+	"s3-check/internal/input"
+)
+
+//go:embed wordlist.txt
+var builtinWordlist string
+
+// Environments are the deployment stage names commonly put in bucket names
+var Environments = []string{"dev", "develop", "development", "test", "testing", "qa", "uat", "stage", "staging", "preprod", "prod", "production", "sandbox", "demo"}
+
+// Regions are the AWS regions commonly put in bucket names
+var Regions = []string{
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	"ca-central-1", "sa-east-1",
+	"eu-west-1", "eu-west-2", "eu-west-3", "eu-central-1", "eu-north-1", "eu-south-1",
+	"ap-south-1", "ap-southeast-1", "ap-southeast-2", "ap-northeast-1", "ap-northeast-2", "ap-east-1",
+	"me-south-1", "af-south-1",
+}
+
+// Separators join the parts of a name. Underscores are not allowed in bucket names.
+var Separators = []string{"", "-", "."}
+
+// Options selects which permutations Generate produces
+type Options struct {
+	// Words are put before and after every keyword
+	Words []string
+	// Environments and Regions are put before and after every keyword, and
+	// environments also after every keyword-word pair
+	Environments []string
+	Regions      []string
+	Separators   []string
+}
+
+// DefaultOptions uses the built-in wordlist, environments, regions and separators
+func DefaultOptions() Options {
+	words, _ := ReadWordlist(strings.NewReader(builtinWordlist))
+	return Options{
+		Words:        words,
+		Environments: Environments,
+		Regions:      Regions,
+		Separators:   Separators,
+	}
+}
+
+// ReadWordlist reads one word per line, skipping blank lines and # comments
+func ReadWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// Generate returns the candidate names for keywords, in a stable order with
+// the most likely names (the keywords themselves) first. Names S3 would
+// reject are left out, and each name appears once.
+func Generate(keywords []string, opts Options) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		if kind, _ := input.ValidateName(name); kind == input.NameGeneral {
+			names = append(names, name)
+		}
+	}
+
+	var keys []string
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			keys = append(keys, keyword)
+		}
+	}
+
+	for _, key := range keys {
+		add(key)
+	}
+	for _, sep := range opts.Separators {
+		// Keyword pairs, e.g. acme-payments
+		for _, a := range keys {
+			for _, b := range keys {
+				if a != b {
+					add(a + sep + b)
+				}
+			}
+		}
+		for _, key := range keys {
+			for _, affixes := range [][]string{opts.Words, opts.Environments, opts.Regions} {
+				for _, affix := range affixes {
+					add(key + sep + affix)
+					add(affix + sep + key)
+				}
+			}
+			// keyword-word-environment, e.g. acme-logs-prod
+			for _, word := range opts.Words {
+				for _, env := range opts.Environments {
+					add(key + sep + word + sep + env)
+				}
+			}
+		}
+	}
+	return names
+}
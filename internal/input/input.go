@@ -0,0 +1,209 @@
+// Package input turns the bucket references found in logs, scanner output and
+// infrastructure code into bucket names. A line may be a bare bucket name, an
+// s3:// URI, an S3 ARN or an HTTPS URL in any of the S3 endpoint styles.
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Target is a bucket reference extracted from one line of input
+type Target struct {
+	Bucket string
+	// Region is the region named by the endpoint, if any; it is only a hint
+	Region string
+	// Key is the object key or prefix that followed the bucket, if any
+	Key string
+}
+
+// LineError reports an input line that does not name a bucket
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrUnrecognized = errors.New("not a bucket name, s3:// URI, S3 ARN or S3 URL")
+	ErrNoBucket     = errors.New("no bucket in reference")
+)
+
+// Read parses every line of r. Blank lines and lines starting with # are
+// ignored. Duplicate buckets are kept, so the caller can report them.
+func Read(r io.Reader) ([]Target, []*LineError, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	targets, problems := Normalize(lines)
+	return targets, problems, nil
+}
+
+// Normalize parses lines as Read does; line numbers in errors start at 1
+func Normalize(lines []string) ([]Target, []*LineError) {
+	var targets []Target
+	var problems []*LineError
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		target, err := Parse(line)
+		if err != nil {
+			problems = append(problems, &LineError{Line: i + 1, Text: line, Err: err})
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets, problems
+}
+
+// Parse extracts the bucket reference from a single line. The name itself is
+// not checked; see ValidateName.
+func Parse(line string) (Target, error) {
+	line = strings.TrimSpace(line)
+
+	var target Target
+	var err error
+	switch lower := strings.ToLower(line); {
+	case strings.HasPrefix(lower, "s3://"), strings.HasPrefix(lower, "s3a://"), strings.HasPrefix(lower, "s3n://"):
+		target, err = parseS3URI(line)
+	case strings.HasPrefix(lower, "arn:"):
+		target, err = parseARN(line)
+	case strings.HasPrefix(lower, "https://"), strings.HasPrefix(lower, "http://"):
+		target, err = parseURL(line)
+	case strings.Contains(lower, ".amazonaws.com"):
+		// A bare endpoint host, e.g. bucket.s3.amazonaws.com/key
+		target, err = parseURL("https://" + line)
+	default:
+		target = Target{Bucket: line}
+	}
+	if err != nil {
+		return Target{}, err
+	}
+	if target.Bucket == "" {
+		return Target{}, ErrNoBucket
+	}
+	return target, nil
+}
+
+// parseS3URI handles s3://bucket/key (and the Hadoop s3a:// and s3n:// schemes)
+func parseS3URI(line string) (Target, error) {
+	_, rest, _ := strings.Cut(line, "://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	return Target{Bucket: bucket, Key: key}, nil
+}
+
+// parseARN handles arn:<partition>:s3:::bucket and arn:<partition>:s3:::bucket/key
+func parseARN(line string) (Target, error) {
+	parts := strings.SplitN(line, ":", 6)
+	if len(parts) != 6 || parts[2] != "s3" {
+		return Target{}, ErrUnrecognized
+	}
+	// Access point, Object Lambda and Outposts ARNs carry an account and
+	// region and name a resource that is not a bucket
+	if parts[3] != "" || parts[4] != "" {
+		return Target{}, fmt.Errorf("%w: only bucket and object ARNs (arn:aws:s3:::bucket) are supported", ErrUnrecognized)
+	}
+	bucket, key, _ := strings.Cut(parts[5], "/")
+	return Target{Bucket: bucket, Key: key}, nil
+}
+
+// parseURL handles the virtual-hosted, path-style, dualstack and website
+// endpoint forms, e.g.
+//
+//	https://bucket.s3.eu-west-1.amazonaws.com/key
+//	https://bucket.s3-website-us-west-2.amazonaws.com/
+//	https://s3.amazonaws.com/bucket/key
+//	https://s3-eu-west-1.amazonaws.com/bucket
+func parseURL(line string) (Target, error) {
+	u, err := url.Parse(line)
+	if err != nil {
+		return Target{}, ErrUnrecognized
+	}
+	host := strings.ToLower(u.Hostname())
+	path := strings.TrimPrefix(u.Path, "/")
+
+	var base string
+	for _, suffix := range []string{".amazonaws.com", ".amazonaws.com.cn"} {
+		if strings.HasSuffix(host, suffix) {
+			base = strings.TrimSuffix(host, suffix)
+		}
+	}
+	if base == "" {
+		return Target{}, ErrUnrecognized
+	}
+
+	// The endpoint is the longest run of trailing labels that all belong to an
+	// S3 endpoint name, starting at an s3 label; anything before it is the
+	// (possibly dotted) bucket name
+	labels := strings.Split(base, ".")
+	start := -1
+	for i := len(labels) - 1; i >= 0 && isEndpointLabel(labels[i]); i-- {
+		if labels[i] == "s3" || strings.HasPrefix(labels[i], "s3-") {
+			start = i
+		}
+	}
+	if start < 0 {
+		return Target{}, ErrUnrecognized
+	}
+	region := regionFromEndpoint(labels[start:])
+	if start == 0 {
+		// Path style: the bucket is the first path segment
+		bucket, key, _ := strings.Cut(path, "/")
+		return Target{Bucket: bucket, Region: region, Key: key}, nil
+	}
+	// Virtual-hosted style; keep the name's case from the original line
+	bucket := u.Hostname()[:len(strings.Join(labels[:start], "."))]
+	return Target{Bucket: bucket, Region: region, Key: path}, nil
+}
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// isEndpointLabel reports whether a host label can be part of an S3
+// endpoint name, e.g. s3, s3-website-us-west-2, dualstack or eu-west-1
+func isEndpointLabel(label string) bool {
+	switch label {
+	case "s3", "dualstack", "fips", "website", "s3-website", "s3-accelerate", "s3-external-1":
+		return true
+	}
+	return regionPattern.MatchString(label) ||
+		regionPattern.MatchString(strings.TrimPrefix(label, "s3-website-")) ||
+		regionPattern.MatchString(strings.TrimPrefix(label, "s3-"))
+}
+
+// regionFromEndpoint reads the region from the endpoint labels that follow
+// the bucket, e.g. [s3 eu-west-1], [s3-eu-west-1], [s3 dualstack eu-west-1]
+// or [s3-website-eu-west-1]. The global endpoint names no region.
+func regionFromEndpoint(labels []string) string {
+	for _, label := range labels {
+		if label == "s3-external-1" {
+			return "us-east-1"
+		}
+		for _, candidate := range []string{label, strings.TrimPrefix(label, "s3-website-"), strings.TrimPrefix(label, "s3-")} {
+			if regionPattern.MatchString(candidate) {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package input
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Target
+	}{
+		{"my-bucket", Target{Bucket: "my-bucket"}},
+		{"  my-bucket  ", Target{Bucket: "my-bucket"}},
+		{"s3://my-bucket", Target{Bucket: "my-bucket"}},
+		{"s3://my-bucket/some/prefix/", Target{Bucket: "my-bucket", Key: "some/prefix/"}},
+		{"s3a://my-bucket/key", Target{Bucket: "my-bucket", Key: "key"}},
+		{"arn:aws:s3:::my-bucket", Target{Bucket: "my-bucket"}},
+		{"arn:aws:s3:::my-bucket/some/key", Target{Bucket: "my-bucket", Key: "some/key"}},
+		{"arn:aws-cn:s3:::my-bucket", Target{Bucket: "my-bucket"}},
+		{"https://my-bucket.s3.eu-west-1.amazonaws.com/key", Target{Bucket: "my-bucket", Region: "eu-west-1", Key: "key"}},
+		{"https://my-bucket.s3.amazonaws.com/", Target{Bucket: "my-bucket"}},
+		{"https://my.dotted.bucket.s3.us-west-2.amazonaws.com/a/b", Target{Bucket: "my.dotted.bucket", Region: "us-west-2", Key: "a/b"}},
+		{"https://my-bucket.s3-eu-west-1.amazonaws.com", Target{Bucket: "my-bucket", Region: "eu-west-1"}},
+		{"https://my-bucket.s3.dualstack.ap-south-1.amazonaws.com/x", Target{Bucket: "my-bucket", Region: "ap-south-1", Key: "x"}},
+		{"http://my-bucket.s3-website-us-west-2.amazonaws.com/", Target{Bucket: "my-bucket", Region: "us-west-2"}},
+		{"http://my-bucket.s3-website.eu-central-1.amazonaws.com/", Target{Bucket: "my-bucket", Region: "eu-central-1"}},
+		{"https://s3.amazonaws.com/my-bucket/key", Target{Bucket: "my-bucket", Key: "key"}},
+		{"https://s3.eu-west-1.amazonaws.com/my-bucket", Target{Bucket: "my-bucket", Region: "eu-west-1"}},
+		{"https://s3-eu-west-1.amazonaws.com/my-bucket", Target{Bucket: "my-bucket", Region: "eu-west-1"}},
+		{"https://my-bucket.s3-external-1.amazonaws.com/", Target{Bucket: "my-bucket", Region: "us-east-1"}},
+		{"https://my-bucket.s3.cn-north-1.amazonaws.com.cn/", Target{Bucket: "my-bucket", Region: "cn-north-1"}},
+		{"my-bucket.s3.eu-west-1.amazonaws.com/key", Target{Bucket: "my-bucket", Region: "eu-west-1", Key: "key"}},
+		{"https://My-Bucket.s3.amazonaws.com/", Target{Bucket: "My-Bucket"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.line)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.line, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		want error
+	}{
+		{"s3://", ErrNoBucket},
+		{"https://s3.amazonaws.com/", ErrNoBucket},
+		{"https://example.com/my-bucket", ErrUnrecognized},
+		{"arn:aws:s3:us-east-1:123456789012:accesspoint/ap", ErrUnrecognized},
+		{"arn:aws:iam::123456789012:role/x", ErrUnrecognized},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.line); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.line, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeReportsLines(t *testing.T) {
+	targets, problems := Normalize([]string{
+		"# comment",
+		"",
+		"s3://first",
+		"https://example.com/nope",
+		"second",
+	})
+	if len(targets) != 2 || targets[0].Bucket != "first" || targets[1].Bucket != "second" {
+		t.Errorf("targets = %+v, want first and second", targets)
+	}
+	if len(problems) != 1 || problems[0].Line != 4 || !errors.Is(problems[0], ErrUnrecognized) {
+		t.Errorf("problems = %v, want line 4 unrecognized", problems)
+	}
+}
+
+func TestNormalizeKeepsDuplicates(t *testing.T) {
+	targets, _ := Normalize([]string{"my-bucket", "my-bucket", "s3://my-bucket/x"})
+	if len(targets) != 3 {
+		t.Errorf("got %d targets, want all 3", len(targets))
+	}
+}
@@ -0,0 +1,102 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// NameKind says which S3 naming rules a bucket name satisfies
+type NameKind string
+
+const (
+	// NameGeneral is a general purpose bucket name under the current rules
+	NameGeneral NameKind = "general"
+	// NameDirectory is a directory bucket name, e.g. logs--usw2-az1--x-s3
+	NameDirectory NameKind = "directory"
+	// NameLegacy is only valid for buckets created in us-east-1 before
+	// March 2018, which may use uppercase letters, underscores and up to 255
+	// characters
+	NameLegacy NameKind = "legacy"
+)
+
+var (
+	ErrNameLength     = errors.New("must be 3 to 63 characters (255 for legacy us-east-1 names)")
+	ErrNameCharacters = errors.New("may only contain lowercase letters, digits, dots and hyphens")
+	ErrNameEdges      = errors.New("must begin and end with a letter or digit")
+	ErrNameDots       = errors.New("must not contain two adjacent dots")
+	ErrNameIP         = errors.New("must not be formatted as an IP address")
+	ErrNameReserved   = errors.New("uses a prefix or suffix reserved by S3")
+	ErrNameDirectory  = errors.New("directory bucket names must be base-name--zone-id--x-s3 in lowercase letters, digits and hyphens")
+)
+
+var (
+	generalNamePattern   = regexp.MustCompile(`^[a-z0-9.-]+$`)
+	legacyNamePattern    = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	directoryNamePattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?--[a-z0-9]+-(?:az|lz)[0-9]+(?:-[a-z0-9]+)?--x-s3$`)
+)
+
+// Prefixes and suffixes S3 reserves for access point aliases, multi-region
+// access points and other resource types
+var (
+	reservedPrefixes = []string{"xn--", "sthree-", "amzn-s3-demo-"}
+	reservedSuffixes = []string{"-s3alias", "--ol-s3", ".mrap", "--table-s3"}
+)
+
+// ValidateName checks name against the S3 bucket naming rules and reports
+// which rules it satisfies. A name that is only valid under the legacy
+// us-east-1 rules is returned as NameLegacy together with the rule the
+// current rules reject it for.
+func ValidateName(name string) (NameKind, error) {
+	if strings.HasSuffix(name, "--x-s3") {
+		if len(name) < 3 || len(name) > 63 || !directoryNamePattern.MatchString(name) {
+			return "", ErrNameDirectory
+		}
+		return NameDirectory, nil
+	}
+
+	err := validateGeneral(name)
+	if err == nil {
+		return NameGeneral, nil
+	}
+	// Reserved names and IP addresses were never allowed
+	if errors.Is(err, ErrNameReserved) || errors.Is(err, ErrNameIP) {
+		return "", err
+	}
+	if len(name) >= 3 && len(name) <= 255 && legacyNamePattern.MatchString(name) {
+		return NameLegacy, err
+	}
+	return "", err
+}
+
+func validateGeneral(name string) error {
+	switch {
+	case len(name) < 3 || len(name) > 63:
+		return ErrNameLength
+	case !generalNamePattern.MatchString(name):
+		return ErrNameCharacters
+	case !isAlnum(name[0]) || !isAlnum(name[len(name)-1]):
+		return ErrNameEdges
+	case strings.Contains(name, ".."):
+		return ErrNameDots
+	case net.ParseIP(name) != nil:
+		return ErrNameIP
+	}
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("%w (%s)", ErrNameReserved, prefix)
+		}
+	}
+	for _, suffix := range reservedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return fmt.Errorf("%w (%s)", ErrNameReserved, suffix)
+		}
+	}
+	return nil
+}
+
+func isAlnum(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+}
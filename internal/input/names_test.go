@@ -0,0 +1,45 @@
+package input
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    NameKind
+		wantErr error
+	}{
+		{"my-bucket", NameGeneral, nil},
+		{"my.dotted.bucket", NameGeneral, nil},
+		{"abc", NameGeneral, nil},
+		{strings.Repeat("a", 63), NameGeneral, nil},
+		{"logs--usw2-az1--x-s3", NameDirectory, nil},
+		{"Logs--usw2-az1--x-s3", "", ErrNameDirectory},
+		{"logs--x-s3", "", ErrNameDirectory},
+		{"ab", "", ErrNameLength},
+		{strings.Repeat("a", 64), NameLegacy, ErrNameLength},
+		{strings.Repeat("a", 256), "", ErrNameLength},
+		{"My_Bucket", NameLegacy, ErrNameCharacters},
+		{"my bucket", "", ErrNameCharacters},
+		{"-bucket", NameLegacy, ErrNameEdges},
+		{"bucket.", NameLegacy, ErrNameEdges},
+		{"my..bucket", NameLegacy, ErrNameDots},
+		{"192.168.5.4", "", ErrNameIP},
+		{"xn--bucket", "", ErrNameReserved},
+		{"sthree-bucket", "", ErrNameReserved},
+		{"bucket-s3alias", "", ErrNameReserved},
+		{"bucket--ol-s3", "", ErrNameReserved},
+	}
+	for _, tt := range tests {
+		kind, err := ValidateName(tt.name)
+		if kind != tt.kind {
+			t.Errorf("ValidateName(%q) kind = %q, want %q", tt.name, kind, tt.kind)
+		}
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ValidateName(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
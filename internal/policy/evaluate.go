@@ -0,0 +1,466 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Audience is who a grant applies to
+type Audience string
+
+const (
+	// Public: anyone, including unsigned (anonymous) requests
+	Public Audience = "public"
+	// Authenticated: any signed request from any AWS account, typically "*"
+	// narrowed by a condition on the calling principal (e.g. aws:PrincipalOrgID)
+	Authenticated Audience = "authenticated"
+	// Account: a specific account, IAM principal or canonical user
+	Account Audience = "account"
+)
+
+// Condition is one condition attached to a grant
+type Condition struct {
+	Operator string   `json:"operator"`
+	Key      string   `json:"key"`
+	Values   []string `json:"values"`
+	// Deny is set for conditions of a Deny statement: the grant only holds
+	// while the condition is false
+	Deny bool `json:"deny,omitempty"`
+	// Narrows is set when the condition restricts who can use the grant
+	Narrows bool `json:"narrows"`
+}
+
+// Grant is access a policy gives to someone other than the bucket owner
+type Grant struct {
+	Sid      string   `json:"sid,omitempty"`
+	Audience Audience `json:"audience"`
+	// Principal is the account, ARN or canonical user ID of account grants
+	Principal    string      `json:"principal,omitempty"`
+	Actions      []string    `json:"actions,omitempty"`
+	NotActions   []string    `json:"not_actions,omitempty"`
+	Resources    []string    `json:"resources,omitempty"`
+	NotResources []string    `json:"not_resources,omitempty"`
+	Conditions   []Condition `json:"conditions,omitempty"`
+	// Narrowed is set when any condition restricts who can use the grant
+	Narrowed bool `json:"narrowed"`
+}
+
+// NarrowedBy lists the keys of the conditions that narrow the grant
+func (g Grant) NarrowedBy() []string {
+	var keys []string
+	for _, condition := range g.Conditions {
+		if condition.Narrows {
+			keys = append(keys, condition.Key)
+		}
+	}
+	return keys
+}
+
+// narrowingKeys restrict who can use a grant when they must match a value.
+// aws:Referer and aws:UserAgent are left out: callers choose them freely.
+var narrowingKeys = map[string]bool{
+	"aws:sourceip":          true,
+	"aws:vpcsourceip":       true,
+	"aws:sourcevpce":        true,
+	"aws:sourcevpc":         true,
+	"aws:sourceaccount":     true,
+	"aws:sourcearn":         true,
+	"aws:sourceorgid":       true,
+	"aws:sourceorgpaths":    true,
+	"aws:principalorgid":    true,
+	"aws:principalorgpaths": true,
+	"aws:principalaccount":  true,
+	"aws:principalarn":      true,
+	"aws:principaltype":     true,
+	"aws:userid":            true,
+	"aws:username":          true,
+}
+
+// principalKeys only exist on signed requests, so a condition that requires
+// them shuts out anonymous callers
+var principalKeys = map[string]bool{
+	"aws:principalorgid":    true,
+	"aws:principalorgpaths": true,
+	"aws:principalaccount":  true,
+	"aws:principalarn":      true,
+	"aws:principaltype":     true,
+	"aws:userid":            true,
+	"aws:username":          true,
+}
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// Analyze lists the grants doc makes to anyone but ownerAccount. When
+// ownerAccount is empty every account grant is reported. Deny statements that
+// apply to everyone either remove the actions they cover (unconditional) or
+// are attached to the grants they overlap as conditions; a Deny with
+// NotPrincipal spares the account grants it lists.
+func Analyze(doc *Document, ownerAccount string) []Grant {
+	var grants []Grant
+	for _, statement := range doc.Statement {
+		if !strings.EqualFold(statement.Effect, "Allow") {
+			continue
+		}
+		conditions := conditionsOf(statement, false)
+		for _, grant := range statementGrants(statement, ownerAccount) {
+			grant.Sid = statement.Sid
+			grant.Actions = statement.Action
+			grant.NotActions = statement.NotAction
+			grant.Resources = statement.Resource
+			grant.NotResources = statement.NotResource
+			grant.Conditions = conditions
+			grants = append(grants, grant)
+		}
+	}
+
+	var kept []Grant
+	for _, grant := range grants {
+		if applyDenies(&grant, doc.Statement) {
+			kept = append(kept, grant)
+		}
+	}
+	for i := range kept {
+		kept[i].Narrowed = len(kept[i].NarrowedBy()) > 0
+		if kept[i].Audience == Public && requiresPrincipal(kept[i].Conditions) {
+			kept[i].Audience = Authenticated
+		}
+	}
+	return kept
+}
+
+// statementGrants splits an Allow statement into one grant per audience
+func statementGrants(statement Statement, ownerAccount string) []Grant {
+	// Allow with NotPrincipal grants everyone except the listed principals
+	if statement.NotPrincipal != nil || (statement.Principal != nil && statement.Principal.Wildcard) {
+		return []Grant{{Audience: Public}}
+	}
+	if statement.Principal == nil {
+		return nil
+	}
+
+	var grants []Grant
+	for _, value := range statement.Principal.Values["AWS"] {
+		if account := accountOf(value); account != "" && account == ownerAccount {
+			continue
+		}
+		grants = append(grants, Grant{Audience: Account, Principal: value})
+	}
+	for _, value := range statement.Principal.Values["CanonicalUser"] {
+		grants = append(grants, Grant{Audience: Account, Principal: value})
+	}
+	// Service and Federated principals act on the owner's behalf
+	return grants
+}
+
+// accountOf extracts the account ID from a bare ID or an IAM ARN
+func accountOf(principal string) string {
+	if accountIDPattern.MatchString(principal) {
+		return principal
+	}
+	parts := strings.SplitN(principal, ":", 6)
+	if len(parts) == 6 && parts[0] == "arn" && accountIDPattern.MatchString(parts[4]) {
+		return parts[4]
+	}
+	return ""
+}
+
+// applyDenies removes the actions of grant that an unconditional Deny for
+// everyone covers and attaches the conditions of conditional Denies that
+// overlap it. It reports whether anything of the grant is left.
+func applyDenies(grant *Grant, statements []Statement) bool {
+	for _, deny := range statements {
+		if !strings.EqualFold(deny.Effect, "Deny") || !appliesToEveryone(deny) {
+			continue
+		}
+		if exempts(deny.NotPrincipal, *grant) {
+			continue
+		}
+		if len(deny.Condition) > 0 {
+			if overlaps(deny, *grant) {
+				grant.Conditions = append(grant.Conditions, conditionsOf(deny, true)...)
+			}
+			continue
+		}
+		if len(grant.NotActions) > 0 || !coversResources(deny, *grant) {
+			// Cannot subtract from "everything except" or from part of the
+			// resources; treat as still granted
+			continue
+		}
+		var remaining []string
+		for _, action := range grant.Actions {
+			if !statementCoversAction(deny, action) {
+				remaining = append(remaining, action)
+			}
+		}
+		if len(remaining) == 0 {
+			return false
+		}
+		grant.Actions = remaining
+	}
+	return true
+}
+
+func appliesToEveryone(statement Statement) bool {
+	return statement.NotPrincipal != nil || (statement.Principal != nil && statement.Principal.Wildcard)
+}
+
+// exempts reports whether the NotPrincipal of a Deny lists the principal of an
+// account grant, which the Deny then does not apply to
+func exempts(notPrincipal *Principal, grant Grant) bool {
+	if notPrincipal == nil || grant.Audience != Account {
+		return false
+	}
+	for _, values := range notPrincipal.Values {
+		for _, value := range values {
+			if value == grant.Principal || sameAccount(value, grant.Principal) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameAccount reports whether a and b both name the same account as a whole,
+// as a bare ID or as its root ARN
+func sameAccount(a, b string) bool {
+	accountA, accountB := accountRoot(a), accountRoot(b)
+	return accountA != "" && accountA == accountB
+}
+
+func accountRoot(principal string) string {
+	if accountIDPattern.MatchString(principal) || strings.HasSuffix(principal, ":root") {
+		return accountOf(principal)
+	}
+	return ""
+}
+
+// overlaps reports whether a Deny statement covers any of the grant's actions
+func overlaps(deny Statement, grant Grant) bool {
+	if len(grant.NotActions) > 0 {
+		return true
+	}
+	for _, action := range grant.Actions {
+		if statementCoversAction(deny, action) {
+			return true
+		}
+	}
+	// A wildcard grant such as s3:* overlaps a Deny for s3:GetObject
+	for _, action := range deny.Action {
+		if actionMatches(action, grant.Actions) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversResources reports whether a Deny statement covers every resource of the grant
+func coversResources(deny Statement, grant Grant) bool {
+	if len(deny.NotResource) > 0 {
+		return false
+	}
+	if len(grant.NotResources) > 0 || len(grant.Resources) == 0 {
+		return resourceMatches("*", deny.Resource)
+	}
+	for _, resource := range grant.Resources {
+		if !resourceMatches(resource, deny.Resource) {
+			return false
+		}
+	}
+	return true
+}
+
+func conditionsOf(statement Statement, deny bool) []Condition {
+	var conditions []Condition
+	operators := make([]string, 0, len(statement.Condition))
+	for operator := range statement.Condition {
+		operators = append(operators, operator)
+	}
+	sort.Strings(operators)
+
+	for _, operator := range operators {
+		keys := make([]string, 0, len(statement.Condition[operator]))
+		for key := range statement.Condition[operator] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			conditions = append(conditions, Condition{
+				Operator: operator,
+				Key:      key,
+				Values:   statement.Condition[operator][key],
+				Deny:     deny,
+				Narrows:  narrows(operator, key, deny),
+			})
+		}
+	}
+	return conditions
+}
+
+// narrows decides whether a condition restricts who can use a grant. An Allow
+// narrows when it requires a key to match (IpAddress, StringEquals); a Deny
+// narrows when it refuses everything that does not match (NotIpAddress,
+// StringNotEquals). IfExists and Null operators let requests without the key
+// through, so they never narrow.
+func narrows(operator, key string, deny bool) bool {
+	lowerKey := strings.ToLower(key)
+	if !narrowingKeys[lowerKey] && !strings.HasPrefix(lowerKey, "aws:principaltag/") {
+		return false
+	}
+	if operator == "Null" || strings.HasSuffix(operator, "IfExists") {
+		return false
+	}
+	negated := strings.Contains(operator, "Not")
+	return negated == deny
+}
+
+// requiresPrincipal reports whether a narrowing condition needs a signed request
+func requiresPrincipal(conditions []Condition) bool {
+	for _, condition := range conditions {
+		key := strings.ToLower(condition.Key)
+		if condition.Narrows && (principalKeys[key] || strings.HasPrefix(key, "aws:principaltag/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the grant to audience that allows action on resource, preferring
+// one no condition narrows. resource is an ARN that may contain wildcards, e.g.
+// arn:aws:s3:::bucket/* finds grants on any object of the bucket.
+func Find(grants []Grant, audience Audience, action, resource string) (Grant, bool) {
+	var found Grant
+	var ok bool
+	for _, grant := range grants {
+		if grant.Audience != audience || !grant.allows(action, resource) {
+			continue
+		}
+		if !grant.Narrowed {
+			return grant, true
+		}
+		if !ok {
+			found, ok = grant, true
+		}
+	}
+	return found, ok
+}
+
+func (g Grant) allows(action, resource string) bool {
+	switch {
+	case len(g.NotActions) > 0:
+		if actionMatches(action, g.NotActions) {
+			return false
+		}
+	case !actionMatches(action, g.Actions):
+		return false
+	}
+	switch {
+	case len(g.NotResources) > 0:
+		return !resourceMatches(resource, g.NotResources)
+	case len(g.Resources) > 0:
+		return resourceOverlaps(resource, g.Resources)
+	}
+	return true
+}
+
+func statementCoversAction(statement Statement, action string) bool {
+	if len(statement.NotAction) > 0 {
+		return !actionMatches(action, statement.NotAction)
+	}
+	return actionMatches(action, statement.Action)
+}
+
+// actionMatches compares case-insensitively, as IAM does for actions
+func actionMatches(action string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if wildcardMatch(strings.ToLower(pattern), strings.ToLower(action)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceMatches ignores the partition, so arn:aws-cn:s3:::b matches arn:aws:s3:::b
+func resourceMatches(resource string, patterns []string) bool {
+	resource = stripPartition(resource)
+	for _, pattern := range patterns {
+		if pattern == "*" || wildcardMatch(stripPartition(pattern), resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceOverlaps reports whether some resource matches both resource and one of patterns
+func resourceOverlaps(resource string, patterns []string) bool {
+	resource = stripPartition(resource)
+	for _, pattern := range patterns {
+		pattern = stripPartition(pattern)
+		if pattern == "*" || wildcardMatch(pattern, resource) || wildcardMatch(resource, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func stripPartition(arn string) string {
+	parts := strings.SplitN(arn, ":", 3)
+	if len(parts) == 3 && parts[0] == "arn" {
+		return parts[2]
+	}
+	return arn
+}
+
+// wildcardMatch matches s against a pattern where * is any run of characters
+// and ? any single character
+func wildcardMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if wildcardMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return s == ""
+}
+
+// String summarises the grant, e.g. "public: s3:GetObject on arn:aws:s3:::b/* (narrowed by aws:SourceIp)"
+func (g Grant) String() string {
+	who := string(g.Audience)
+	if g.Principal != "" {
+		who += " " + g.Principal
+	}
+	actions := strings.Join(g.Actions, ", ")
+	if len(g.NotActions) > 0 {
+		actions = "all actions except " + strings.Join(g.NotActions, ", ")
+	}
+	resources := strings.Join(g.Resources, ", ")
+	if len(g.NotResources) > 0 {
+		resources = "all resources except " + strings.Join(g.NotResources, ", ")
+	}
+	text := fmt.Sprintf("%s: %s on %s", who, actions, resources)
+	if keys := g.NarrowedBy(); len(keys) > 0 {
+		text += " (narrowed by " + strings.Join(keys, ", ") + ")"
+	}
+	return text
+}
@@ -0,0 +1,152 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+const owner = "111122223333"
+
+// grantSummary is the part of a Grant the tests compare
+type grantSummary struct {
+	Audience  Audience
+	Principal string
+	Actions   []string
+	Narrowed  bool
+}
+
+func analyze(t *testing.T, policy string) []grantSummary {
+	t.Helper()
+	doc, err := Parse([]byte(policy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var summaries []grantSummary
+	for _, grant := range Analyze(doc, owner) {
+		summaries = append(summaries, grantSummary{grant.Audience, grant.Principal, grant.Actions, grant.Narrowed})
+	}
+	return summaries
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   []grantSummary
+	}{
+		{
+			name:   "public read",
+			policy: `{"Statement":{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}}`,
+			want:   []grantSummary{{Audience: Public, Actions: []string{"s3:GetObject"}}},
+		},
+		{
+			name:   "AWS wildcard principal",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":"s3:ListBucket","Resource":"arn:aws:s3:::b"}]}`,
+			want:   []grantSummary{{Audience: Public, Actions: []string{"s3:ListBucket"}}},
+		},
+		{
+			name:   "NotPrincipal",
+			policy: `{"Statement":[{"Effect":"Allow","NotPrincipal":{"AWS":"arn:aws:iam::444455556666:root"},"Action":"s3:*","Resource":"*"}]}`,
+			want:   []grantSummary{{Audience: Public, Actions: []string{"s3:*"}}},
+		},
+		{
+			name:   "narrowed by source IP",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"*","Condition":{"IpAddress":{"aws:SourceIp":"203.0.113.0/24"}}}]}`,
+			want:   []grantSummary{{Audience: Public, Actions: []string{"s3:GetObject"}, Narrowed: true}},
+		},
+		{
+			name:   "referer does not narrow",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"*","Condition":{"StringLike":{"aws:Referer":"https://example.com/*"}}}]}`,
+			want:   []grantSummary{{Audience: Public, Actions: []string{"s3:GetObject"}}},
+		},
+		{
+			name:   "organization makes it authenticated",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"*","Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-abc"}}}]}`,
+			want:   []grantSummary{{Audience: Authenticated, Actions: []string{"s3:GetObject"}, Narrowed: true}},
+		},
+		{
+			name: "cross-account, owner left out",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::444455556666:root","arn:aws:iam::111122223333:role/r"],
+				"CanonicalUser":"79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be"},"Action":"s3:PutObject","Resource":"*"}]}`,
+			want: []grantSummary{
+				{Audience: Account, Principal: "arn:aws:iam::444455556666:root", Actions: []string{"s3:PutObject"}},
+				{Audience: Account, Principal: "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be", Actions: []string{"s3:PutObject"}},
+			},
+		},
+		{
+			name:   "service principals are not reported",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"Service":"logging.s3.amazonaws.com"},"Action":"s3:PutObject","Resource":"*"}]}`,
+		},
+		{
+			name: "unconditional deny removes actions",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":"*","Action":["s3:GetObject","s3:PutObject"],"Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Deny","Principal":"*","Action":"s3:Put*","Resource":"arn:aws:s3:::b/*"}]}`,
+			want: []grantSummary{{Audience: Public, Actions: []string{"s3:GetObject"}}},
+		},
+		{
+			name: "deny on part of the resources leaves the grant",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Deny","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/private/*"}]}`,
+			want: []grantSummary{{Audience: Public, Actions: []string{"s3:GetObject"}}},
+		},
+		{
+			name: "deny outside a VPC endpoint narrows",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"StringNotEquals":{"aws:SourceVpce":"vpce-1"}}}]}`,
+			want: []grantSummary{{Audience: Public, Actions: []string{"s3:GetObject"}, Narrowed: true}},
+		},
+		{
+			name: "deny without TLS does not narrow",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"Bool":{"aws:SecureTransport":false}}}]}`,
+			want: []grantSummary{{Audience: Public, Actions: []string{"s3:GetObject"}}},
+		},
+		{
+			name: "deny with NotPrincipal spares the exempted account",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::444455556666:root"},"Action":"s3:*","Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"]},
+				{"Effect":"Deny","NotPrincipal":{"AWS":"arn:aws:iam::444455556666:root"},"Action":"s3:*","Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"]}]}`,
+			want: []grantSummary{{Audience: Account, Principal: "arn:aws:iam::444455556666:root", Actions: []string{"s3:*"}}},
+		},
+		{
+			name: "deny with NotPrincipal removes accounts it does not list",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":["444455556666","777788889999"]},"Action":"s3:GetObject","Resource":"*"},
+				{"Effect":"Deny","NotPrincipal":{"AWS":"arn:aws:iam::444455556666:root"},"Action":"s3:*","Resource":"*"}]}`,
+			want: []grantSummary{{Audience: Account, Principal: "444455556666", Actions: []string{"s3:GetObject"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analyze(t, tt.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("grants = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	doc, err := Parse([]byte(`{"Statement":[
+		{"Effect":"Allow","Principal":"*","Action":"s3:Get*","Resource":"arn:aws:s3:::b/public/*"},
+		{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants := Analyze(doc, owner)
+
+	// A grant on a prefix overlaps the whole bucket, and unnarrowed grants win
+	grant, found := Find(grants, Public, "s3:GetObject", "arn:aws:s3:::b/*")
+	if !found || grant.Narrowed {
+		t.Errorf("Find(GetObject) = %+v, %v; want the unnarrowed prefix grant", grant, found)
+	}
+	if _, found := Find(grants, Public, "s3:PutObject", "arn:aws:s3:::b/*"); found {
+		t.Error("Find(PutObject) found a grant")
+	}
+	if _, found := Find(grants, Account, "s3:GetObject", "arn:aws:s3:::b/*"); found {
+		t.Error("Find(Account) found a public grant")
+	}
+}
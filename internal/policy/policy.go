@@ -0,0 +1,145 @@
+// Package policy parses S3 bucket policies and works out who they grant
+// access to: anyone (including anonymous callers), any authenticated AWS
+// principal, or specific accounts, and which Conditions narrow each grant.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Document is an IAM policy document
+type Document struct {
+	Version   string      `json:"Version"`
+	ID        string      `json:"Id,omitempty"`
+	Statement []Statement `json:"Statement"`
+}
+
+// Statement is one policy statement. Exactly one of Principal and
+// NotPrincipal, Action and NotAction, Resource and NotResource is set.
+type Statement struct {
+	Sid          string     `json:"Sid,omitempty"`
+	Effect       string     `json:"Effect"`
+	Principal    *Principal `json:"Principal,omitempty"`
+	NotPrincipal *Principal `json:"NotPrincipal,omitempty"`
+	Action       Strings    `json:"Action,omitempty"`
+	NotAction    Strings    `json:"NotAction,omitempty"`
+	Resource     Strings    `json:"Resource,omitempty"`
+	NotResource  Strings    `json:"NotResource,omitempty"`
+	// Condition maps operators (e.g. IpAddress) to keys (e.g. aws:SourceIp) to values
+	Condition map[string]map[string]Strings `json:"Condition,omitempty"`
+}
+
+// Principal is either "*" (Wildcard) or a map of principal types (AWS,
+// Service, Federated, CanonicalUser) to identifiers
+type Principal struct {
+	Wildcard bool
+	Values   map[string]Strings
+}
+
+// Strings is a policy value that may be written as a single string or a list.
+// Booleans and numbers, as in {"Bool": {"aws:SecureTransport": false}}, are
+// kept as their literal text.
+type Strings []string
+
+func (s *Strings) UnmarshalJSON(data []byte) error {
+	if single, ok := scalarText(data); ok {
+		*s = Strings{single}
+		return nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings: %s", data)
+	}
+	values := make(Strings, 0, len(list))
+	for _, item := range list {
+		value, ok := scalarText(item)
+		if !ok {
+			return fmt.Errorf("expected a string or a list of strings: %s", data)
+		}
+		values = append(values, value)
+	}
+	*s = values
+	return nil
+}
+
+// scalarText reads a JSON string, boolean or number as text
+func scalarText(data []byte) (string, bool) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool, float64:
+		return string(bytes.TrimSpace(data)), true
+	}
+	return "", false
+}
+
+func (p *Principal) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single != "*" {
+			return fmt.Errorf("principal %q: only \"*\" may be given as a bare string", single)
+		}
+		p.Wildcard = true
+		return nil
+	}
+	if err := json.Unmarshal(data, &p.Values); err != nil {
+		return fmt.Errorf("expected \"*\" or a map of principals: %s", data)
+	}
+	// {"AWS": "*"} means the same as "*"
+	for _, value := range p.Values["AWS"] {
+		if value == "*" {
+			p.Wildcard = true
+		}
+	}
+	return nil
+}
+
+func (p Principal) MarshalJSON() ([]byte, error) {
+	if p.Wildcard && len(p.Values) == 0 {
+		return json.Marshal("*")
+	}
+	return json.Marshal(p.Values)
+}
+
+// ErrNoStatements is returned for documents without any statement
+var ErrNoStatements = errors.New("policy has no statements")
+
+// Parse reads a policy document. Statement may be a single object or a list.
+func Parse(data []byte) (*Document, error) {
+	var raw struct {
+		Version   string          `json:"Version"`
+		ID        string          `json:"Id"`
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing policy: %w", err)
+	}
+
+	doc := &Document{Version: raw.Version, ID: raw.ID}
+	trimmed := bytes.TrimSpace(raw.Statement)
+	switch {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")):
+		return nil, ErrNoStatements
+	case trimmed[0] == '{':
+		var statement Statement
+		if err := json.Unmarshal(trimmed, &statement); err != nil {
+			return nil, fmt.Errorf("parsing policy statement: %w", err)
+		}
+		doc.Statement = []Statement{statement}
+	default:
+		if err := json.Unmarshal(trimmed, &doc.Statement); err != nil {
+			return nil, fmt.Errorf("parsing policy statements: %w", err)
+		}
+	}
+	if len(doc.Statement) == 0 {
+		return nil, ErrNoStatements
+	}
+	return doc, nil
+}
@@ -0,0 +1,46 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNonStringConditionValues(t *testing.T) {
+	doc, err := Parse([]byte(`{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:*",
+			"Resource": "arn:aws:s3:::b/*",
+			"Condition": {
+				"Bool": {"aws:SecureTransport": false},
+				"NumericLessThanEquals": {"s3:max-keys": 10},
+				"NumericEquals": {"s3:signatureAge": [600000, 1.5]}
+			}
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	conditions := doc.Statement[0].Condition
+	for _, tt := range []struct {
+		operator, key string
+		want          Strings
+	}{
+		{"Bool", "aws:SecureTransport", Strings{"false"}},
+		{"NumericLessThanEquals", "s3:max-keys", Strings{"10"}},
+		{"NumericEquals", "s3:signatureAge", Strings{"600000", "1.5"}},
+	} {
+		if got := conditions[tt.operator][tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s = %q, want %q", tt.operator, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsObjectValues(t *testing.T) {
+	_, err := Parse([]byte(`{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": {"s3": "GetObject"}, "Resource": "*"}]}`))
+	if err == nil {
+		t.Fatal("Parse accepted an object as Action")
+	}
+}
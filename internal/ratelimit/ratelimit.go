@@ -0,0 +1,170 @@
+// Package ratelimit provides the token-bucket limiters and retry backoff used
+// to keep the checker under S3's request rate limits.
+package ratelimit
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// Backoff bounds for retried requests
+	baseBackoff = 200 * time.Millisecond
+	maxBackoff  = 20 * time.Second
+
+	// A throttled limiter never drops below this fraction of its configured rate
+	minRateFraction = 0.1
+	// Each successful request wins back this fraction of the configured rate
+	recoverFraction = 0.05
+)
+
+// Limiter is a token bucket that adapts its rate: Throttle halves it when S3
+// pushes back and Recover raises it again as requests succeed. A nil
+// *Limiter imposes no limit.
+type Limiter struct {
+	mu         sync.Mutex
+	configured float64
+	rate       float64
+	burst      float64
+	tokens     float64
+	last       time.Time
+}
+
+// New returns a limiter allowing rps requests per second with bursts of up to
+// burst requests. It returns nil (unlimited) when rps is not positive.
+func New(rps float64, burst int) *Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		configured: rps,
+		rate:       rps,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		last:       time.Now(),
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// Reserve a token now, going into debt if needed, so waiters are served in order
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Throttle halves the current rate after S3 asked us to slow down
+func (l *Limiter) Throttle() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate /= 2
+	if floor := l.configured * minRateFraction; l.rate < floor {
+		l.rate = floor
+	}
+}
+
+// Recover nudges the rate back towards the configured one after a success
+func (l *Limiter) Recover() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate < l.configured {
+		l.rate += l.configured * recoverFraction
+		if l.rate > l.configured {
+			l.rate = l.configured
+		}
+	}
+}
+
+// Keyed hands out one Limiter per key (e.g. per bucket), created on first use
+type Keyed struct {
+	mu       sync.Mutex
+	rps      float64
+	burst    int
+	limiters map[string]*Limiter
+}
+
+// NewKeyed returns per-key limiters with the given rate. It returns nil
+// (unlimited) when rps is not positive.
+func NewKeyed(rps float64, burst int) *Keyed {
+	if rps <= 0 {
+		return nil
+	}
+	return &Keyed{rps: rps, burst: burst, limiters: make(map[string]*Limiter)}
+}
+
+// Get returns the limiter for key; nil when k is nil
+func (k *Keyed) Get(key string) *Limiter {
+	if k == nil {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, ok := k.limiters[key]
+	if !ok {
+		l = New(k.rps, k.burst)
+		k.limiters[key] = l
+	}
+	return l
+}
+
+// Backoff returns how long to wait before retry number attempt (starting at
+// 0), using exponential backoff with full jitter
+func Backoff(attempt int) time.Duration {
+	ceiling := maxBackoff
+	if attempt < 16 {
+		if d := baseBackoff << uint(attempt); d < maxBackoff {
+			ceiling = d
+		}
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// Sleep waits for d or until ctx is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	for attempt := 0; attempt < 40; attempt++ {
+		ceiling := maxBackoff
+		if attempt < 16 && baseBackoff<<uint(attempt) < maxBackoff {
+			ceiling = baseBackoff << uint(attempt)
+		}
+		for i := 0; i < 200; i++ {
+			if d := Backoff(attempt); d <= 0 || d > ceiling {
+				t.Fatalf("Backoff(%d) = %s, want in (0, %s]", attempt, d, ceiling)
+			}
+		}
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	// Full jitter spreads waits over the whole range rather than clustering at the ceiling
+	var low, high bool
+	for i := 0; i < 1000; i++ {
+		d := Backoff(3)
+		low = low || d < baseBackoff<<3/4
+		high = high || d > baseBackoff<<3*3/4
+	}
+	if !low || !high {
+		t.Errorf("Backoff(3) not spread over its range (low %v, high %v)", low, high)
+	}
+}
+
+func TestThrottleFloor(t *testing.T) {
+	l := New(100, 1)
+	for i := 0; i < 20; i++ {
+		l.Throttle()
+	}
+	if want := 100 * minRateFraction; l.rate != want {
+		t.Errorf("rate after repeated Throttle = %v, want floor %v", l.rate, want)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	l := New(100, 1)
+	l.Throttle()
+	if l.rate != 50 {
+		t.Fatalf("rate after Throttle = %v, want 50", l.rate)
+	}
+	l.Recover()
+	if want := 50 + 100*recoverFraction; l.rate != want {
+		t.Errorf("rate after Recover = %v, want %v", l.rate, want)
+	}
+	for i := 0; i < 100; i++ {
+		l.Recover()
+	}
+	if l.rate != 100 {
+		t.Errorf("rate after many Recover = %v, want the configured 100", l.rate)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	l.Throttle()
+	l.Recover()
+	if New(0, 1) != nil || NewKeyed(-1, 1).Get("b") != nil {
+		t.Error("a non-positive rate should give an unlimited (nil) limiter")
+	}
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if time.Since(start) > time.Second {
+		t.Error("nil limiter waited")
+	}
+}
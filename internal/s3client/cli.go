@@ -0,0 +1,434 @@
+package s3client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cliOp describes how an operation maps onto an `aws s3api` subcommand and
+// how its JSON output is turned back into the XML the REST API would return
+type cliOp struct {
+	// service is the CLI command group; empty means s3api
+	service string
+	command string
+	// args are fixed arguments always passed to the command
+	args []string
+	// root is the XML element wrapping the converted output; "Outer>Inner"
+	// nests it the way STS responses are
+	root string
+	// unwrap selects a single top-level key of the JSON output as the payload;
+	// a scalar value becomes the text of the root element
+	unwrap string
+	// raw returns the string value of this JSON key verbatim (e.g. bucket policies)
+	raw string
+	// lists maps JSON array keys to their XML form: "Outer>Inner" wraps the
+	// items in Outer, a bare name repeats each item as that element
+	lists map[string]string
+	// headers maps top-level JSON keys to the response headers S3 would send
+	headers map[string]string
+}
+
+var cliOperations = map[Op]cliOp{
+	OpListBuckets: {
+		command: "list-buckets",
+		root:    "ListAllMyBucketsResult",
+		lists:   map[string]string{"Buckets": "Buckets>Bucket"},
+	},
+	OpGetBucketAcl: {
+		command: "get-bucket-acl",
+		root:    "AccessControlPolicy",
+		lists:   map[string]string{"Grants": "AccessControlList>Grant"},
+	},
+	OpHeadBucket: {
+		command: "head-bucket",
+		headers: map[string]string{"BucketRegion": "X-Amz-Bucket-Region"},
+	},
+	OpGetBucketLocation: {
+		command: "get-bucket-location",
+		root:    "LocationConstraint",
+		unwrap:  "LocationConstraint",
+	},
+	OpPutBucketAcl:    {command: "put-bucket-acl"},
+	OpGetBucketPolicy: {command: "get-bucket-policy", raw: "Policy"},
+	OpGetPublicAccessBlock: {
+		command: "get-public-access-block",
+		root:    "PublicAccessBlockConfiguration",
+		unwrap:  "PublicAccessBlockConfiguration",
+	},
+	OpGetBucketOwnershipControls: {
+		command: "get-bucket-ownership-controls",
+		root:    "OwnershipControls",
+		unwrap:  "OwnershipControls",
+		lists:   map[string]string{"Rules": "Rule"},
+	},
+	OpGetBucketCors: {
+		command: "get-bucket-cors",
+		root:    "CORSConfiguration",
+		lists: map[string]string{
+			"CORSRules":      "CORSRule",
+			"AllowedOrigins": "AllowedOrigin",
+			"AllowedMethods": "AllowedMethod",
+			"AllowedHeaders": "AllowedHeader",
+			"ExposeHeaders":  "ExposeHeader",
+		},
+	},
+	OpGetBucketWebsite: {
+		command: "get-bucket-website",
+		root:    "WebsiteConfiguration",
+		lists:   map[string]string{"RoutingRules": "RoutingRules>RoutingRule"},
+	},
+	OpGetBucketLogging: {
+		command: "get-bucket-logging",
+		root:    "BucketLoggingStatus",
+		lists:   map[string]string{"TargetGrants": "TargetGrants>Grant"},
+	},
+	OpGetBucketVersioning: {
+		command: "get-bucket-versioning",
+		root:    "VersioningConfiguration",
+	},
+	OpGetBucketEncryption: {
+		command: "get-bucket-encryption",
+		root:    "ServerSideEncryptionConfiguration",
+		unwrap:  "ServerSideEncryptionConfiguration",
+		lists:   map[string]string{"Rules": "Rule"},
+	},
+	OpGetBucketLifecycle: {
+		command: "get-bucket-lifecycle-configuration",
+		root:    "LifecycleConfiguration",
+		lists: map[string]string{
+			"Rules":                        "Rule",
+			"Transitions":                  "Transition",
+			"NoncurrentVersionTransitions": "NoncurrentVersionTransition",
+		},
+	},
+	OpGetBucketTagging: {
+		command: "get-bucket-tagging",
+		root:    "Tagging",
+		lists:   map[string]string{"TagSet": "TagSet>Tag"},
+	},
+	OpListObjectsV2: {
+		command: "list-objects-v2",
+		args:    []string{"--no-paginate"},
+		root:    "ListBucketResult",
+	},
+	OpListObjectVersions: {
+		command: "list-object-versions",
+		args:    []string{"--no-paginate"},
+		root:    "ListVersionsResult",
+		lists:   map[string]string{"Versions": "Version", "DeleteMarkers": "DeleteMarker"},
+	},
+	OpHeadObject:   {command: "head-object"},
+	OpPutObject:    {command: "put-object"},
+	OpDeleteObject: {command: "delete-object"},
+
+	OpGetCallerIdentity: {
+		service: "sts",
+		command: "get-caller-identity",
+		root:    "GetCallerIdentityResponse>GetCallerIdentityResult",
+	},
+	OpGetAccountPublicAccessBlock: {
+		service: "s3control",
+		command: "get-public-access-block",
+		root:    "PublicAccessBlockConfiguration",
+		unwrap:  "PublicAccessBlockConfiguration",
+	},
+}
+
+// cliErrorPattern matches the error line printed by the AWS CLI, e.g.
+// "An error occurred (AccessDenied) when calling the GetBucketAcl operation: Access Denied"
+var cliErrorPattern = regexp.MustCompile(`An error occurred \(([^)]+)\) when calling the \w+ operation(?: \([^)]*\))?: ?(.*)`)
+
+// cliStatusCodes maps error codes reported by the CLI back to their HTTP status
+var cliStatusCodes = map[string]int{
+	"AccessDenied":                                   http.StatusForbidden,
+	"AllAccessDisabled":                              http.StatusForbidden,
+	"AccountProblem":                                 http.StatusForbidden,
+	"InvalidAccessKeyId":                             http.StatusForbidden,
+	"SignatureDoesNotMatch":                          http.StatusForbidden,
+	"ExpiredToken":                                   http.StatusBadRequest,
+	"NoSuchBucket":                                   http.StatusNotFound,
+	"NoSuchKey":                                      http.StatusNotFound,
+	"NoSuchBucketPolicy":                             http.StatusNotFound,
+	"NoSuchPublicAccessBlockConfiguration":           http.StatusNotFound,
+	"OwnershipControlsNotFoundError":                 http.StatusNotFound,
+	"NoSuchCORSConfiguration":                        http.StatusNotFound,
+	"NoSuchWebsiteConfiguration":                     http.StatusNotFound,
+	"NoSuchLifecycleConfiguration":                   http.StatusNotFound,
+	"NoSuchTagSet":                                   http.StatusNotFound,
+	"ServerSideEncryptionConfigurationNotFoundError": http.StatusNotFound,
+	"PermanentRedirect":                              http.StatusMovedPermanently,
+	"TemporaryRedirect":                              http.StatusTemporaryRedirect,
+	"SlowDown":                                       http.StatusServiceUnavailable,
+	"ServiceUnavailable":                             http.StatusServiceUnavailable,
+	"InternalError":                                  http.StatusInternalServerError,
+}
+
+// CLI sends requests through the AWS CLI (`aws s3api ...`). It is slower than
+// the native client but honours everything configured for the CLI, such as SSO
+// profiles and credential_process.
+type CLI struct {
+	region string
+}
+
+func NewCLI() *CLI {
+	return &CLI{region: LoadRegion()}
+}
+
+// Region returns the default region
+func (c *CLI) Region() string {
+	return c.region
+}
+
+// Do runs the CLI command for req and converts its output into a Response
+func (c *CLI) Do(ctx context.Context, req *Request) (*Response, error) {
+	op, ok := cliOperations[req.Op]
+	if !ok {
+		return nil, fmt.Errorf("unsupported operation %q", req.Op)
+	}
+
+	service := op.service
+	if service == "" {
+		service = "s3api"
+	}
+	args := append([]string{service, op.command, "--output", "json"}, op.args...)
+	if req.Bucket != "" {
+		args = append(args, "--bucket", req.Bucket)
+	}
+	if req.AccountID != "" {
+		args = append(args, "--account-id", req.AccountID)
+	}
+	if req.ExpectedBucketOwner != "" {
+		args = append(args, "--expected-bucket-owner", req.ExpectedBucketOwner)
+	}
+	if req.Key != "" {
+		args = append(args, "--key", req.Key)
+	}
+	if req.Region != "" {
+		args = append(args, "--region", req.Region)
+	}
+	if req.Anonymous {
+		args = append(args, "--no-sign-request")
+	}
+	if maxKeys := req.Query.Get("max-keys"); maxKeys != "" {
+		args = append(args, "--max-keys", maxKeys)
+	}
+
+	switch req.Op {
+	case OpPutObject:
+		tmpFile, err := writeTempFile(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmpFile)
+		args = append(args, "--body", tmpFile)
+	case OpPutBucketAcl:
+		policy, err := aclXMLToJSON(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--access-control-policy", string(policy))
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "aws", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return cliErrorResponse(stderr.String(), err)
+	}
+
+	body, header, err := convertCLIOutput(op, stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("converting %s output: %w", req.Op, err)
+	}
+	return &Response{StatusCode: http.StatusOK, Header: header, Body: body}, nil
+}
+
+// cliErrorResponse turns a CLI service error into the equivalent error response.
+// Anything that is not a service error (missing CLI, no credentials) is returned as an error.
+func cliErrorResponse(stderr string, runErr error) (*Response, error) {
+	match := cliErrorPattern.FindStringSubmatch(stderr)
+	if match == nil {
+		return nil, fmt.Errorf("aws cli: %v: %s", runErr, strings.TrimSpace(stderr))
+	}
+
+	code, message := match[1], strings.TrimSpace(match[2])
+	// HEAD requests have no body, so the CLI reports the bare status code
+	if status, err := strconv.Atoi(code); err == nil {
+		return &Response{StatusCode: status, Header: http.Header{}}, nil
+	}
+
+	status, ok := cliStatusCodes[code]
+	if !ok {
+		status = http.StatusBadRequest
+	}
+	var body bytes.Buffer
+	body.WriteString("<Error><Code>")
+	xml.EscapeText(&body, []byte(code))
+	body.WriteString("</Code><Message>")
+	xml.EscapeText(&body, []byte(message))
+	body.WriteString("</Message></Error>")
+	return &Response{StatusCode: status, Header: http.Header{}, Body: body.Bytes()}, nil
+}
+
+func convertCLIOutput(op cliOp, output []byte) ([]byte, http.Header, error) {
+	header := http.Header{}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, header, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+
+	for key, name := range op.headers {
+		if value, ok := doc[key].(string); ok {
+			header.Set(name, value)
+		}
+	}
+
+	switch {
+	case op.raw != "":
+		value, _ := doc[op.raw].(string)
+		return []byte(value), header, nil
+	case op.root == "":
+		return nil, header, nil
+	}
+
+	var b bytes.Buffer
+	if op.unwrap != "" {
+		inner, isMap := doc[op.unwrap].(map[string]interface{})
+		if !isMap {
+			writeXMLElement(&b, op.root, doc[op.unwrap], op.lists)
+			return b.Bytes(), header, nil
+		}
+		doc = inner
+	}
+	roots := strings.Split(op.root, ">")
+	for _, root := range roots {
+		b.WriteString("<" + root + ">")
+	}
+	writeXMLFields(&b, doc, op.lists)
+	for i := len(roots) - 1; i >= 0; i-- {
+		b.WriteString("</" + roots[i] + ">")
+	}
+	return b.Bytes(), header, nil
+}
+
+func writeXMLFields(b *bytes.Buffer, fields map[string]interface{}, lists map[string]string) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		items, isList := fields[key].([]interface{})
+		if !isList {
+			writeXMLElement(b, key, fields[key], lists)
+			continue
+		}
+
+		outer, inner := "", key
+		if mapped, ok := lists[key]; ok {
+			if o, i, nested := strings.Cut(mapped, ">"); nested {
+				outer, inner = o, i
+			} else {
+				inner = mapped
+			}
+		}
+		if outer != "" {
+			b.WriteString("<" + outer + ">")
+		}
+		for _, item := range items {
+			writeXMLElement(b, inner, item, lists)
+		}
+		if outer != "" {
+			b.WriteString("</" + outer + ">")
+		}
+	}
+}
+
+func writeXMLElement(b *bytes.Buffer, name string, value interface{}, lists map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// Grantees carry their type as an xsi:type attribute on the wire
+		if grantType, ok := v["Type"].(string); ok && name == "Grantee" {
+			fmt.Fprintf(b, `<Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="%s">`, grantType)
+			rest := make(map[string]interface{}, len(v))
+			for k, field := range v {
+				if k != "Type" {
+					rest[k] = field
+				}
+			}
+			writeXMLFields(b, rest, lists)
+			b.WriteString("</Grantee>")
+			return
+		}
+		b.WriteString("<" + name + ">")
+		writeXMLFields(b, v, lists)
+		b.WriteString("</" + name + ">")
+	case nil:
+		b.WriteString("<" + name + "/>")
+	default:
+		b.WriteString("<" + name + ">")
+		xml.EscapeText(b, []byte(fmt.Sprint(v)))
+		b.WriteString("</" + name + ">")
+	}
+}
+
+// aclXMLToJSON converts an AccessControlPolicy document into the JSON form
+// accepted by `put-bucket-acl --access-control-policy`
+func aclXMLToJSON(body []byte) ([]byte, error) {
+	type grantee struct {
+		Type         string `xml:"type,attr" json:"Type"`
+		ID           string `json:"ID,omitempty"`
+		DisplayName  string `json:"DisplayName,omitempty"`
+		URI          string `json:"URI,omitempty"`
+		EmailAddress string `json:"EmailAddress,omitempty"`
+	}
+	var policy struct {
+		Owner struct {
+			ID          string `json:"ID,omitempty"`
+			DisplayName string `json:"DisplayName,omitempty"`
+		}
+		Grants []struct {
+			Grantee    grantee
+			Permission string
+		} `xml:"AccessControlList>Grant"`
+	}
+	if err := xml.Unmarshal(body, &policy); err != nil {
+		return nil, err
+	}
+	return json.Marshal(policy)
+}
+
+func writeTempFile(data []byte) (string, error) {
+	file, err := os.CreateTemp("", "s3-check-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,287 @@
+// Package s3client is a small, dependency free S3 client that speaks the REST
+// API directly over HTTP. Requests are signed with SigV4, or sent unsigned
+// when probing anonymous access.
+package s3client
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Hash of an empty body, used for GET/HEAD/DELETE requests
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// ErrInvalidRequest is wrapped by errors for requests that could not be built,
+// and so were never sent
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Op names an S3 API operation
+type Op string
+
+const (
+	OpListBuckets                Op = "ListBuckets"
+	OpHeadBucket                 Op = "HeadBucket"
+	OpGetBucketLocation          Op = "GetBucketLocation"
+	OpGetBucketAcl               Op = "GetBucketAcl"
+	OpPutBucketAcl               Op = "PutBucketAcl"
+	OpGetBucketPolicy            Op = "GetBucketPolicy"
+	OpGetPublicAccessBlock       Op = "GetPublicAccessBlock"
+	OpGetBucketOwnershipControls Op = "GetBucketOwnershipControls"
+	OpGetBucketCors              Op = "GetBucketCors"
+	OpGetBucketWebsite           Op = "GetBucketWebsite"
+	OpGetBucketLogging           Op = "GetBucketLogging"
+	OpGetBucketVersioning        Op = "GetBucketVersioning"
+	OpGetBucketEncryption        Op = "GetBucketEncryption"
+	OpGetBucketLifecycle         Op = "GetBucketLifecycleConfiguration"
+	OpGetBucketTagging           Op = "GetBucketTagging"
+	OpListObjectsV2              Op = "ListObjectsV2"
+	OpListObjectVersions         Op = "ListObjectVersions"
+	OpHeadObject                 Op = "HeadObject"
+	OpPutObject                  Op = "PutObject"
+	OpDeleteObject               Op = "DeleteObject"
+
+	// OpGetCallerIdentity is the STS call that tells us our own account ID
+	OpGetCallerIdentity Op = "GetCallerIdentity"
+	// OpGetAccountPublicAccessBlock is the S3 Control GetPublicAccessBlock call
+	// for the account-level Block Public Access settings
+	OpGetAccountPublicAccessBlock Op = "GetAccountPublicAccessBlock"
+)
+
+// Services other than S3 that an operation can be sent to
+const (
+	serviceSTS       = "sts"
+	serviceS3Control = "s3-control"
+)
+
+// opSpec describes how an operation maps onto the REST API
+type opSpec struct {
+	method string
+	// subresource is the bare query parameter selecting a bucket sub-resource (e.g. "acl")
+	subresource string
+	// query holds fixed parameters the operation always sends
+	query map[string]string
+	// service is the API the operation belongs to; empty means S3
+	service string
+	// path is the fixed request path of S3 Control operations
+	path string
+}
+
+var operations = map[Op]opSpec{
+	OpListBuckets:                {method: http.MethodGet},
+	OpHeadBucket:                 {method: http.MethodHead},
+	OpGetBucketLocation:          {method: http.MethodGet, subresource: "location"},
+	OpGetBucketAcl:               {method: http.MethodGet, subresource: "acl"},
+	OpPutBucketAcl:               {method: http.MethodPut, subresource: "acl"},
+	OpGetBucketPolicy:            {method: http.MethodGet, subresource: "policy"},
+	OpGetPublicAccessBlock:       {method: http.MethodGet, subresource: "publicAccessBlock"},
+	OpGetBucketOwnershipControls: {method: http.MethodGet, subresource: "ownershipControls"},
+	OpGetBucketCors:              {method: http.MethodGet, subresource: "cors"},
+	OpGetBucketWebsite:           {method: http.MethodGet, subresource: "website"},
+	OpGetBucketLogging:           {method: http.MethodGet, subresource: "logging"},
+	OpGetBucketVersioning:        {method: http.MethodGet, subresource: "versioning"},
+	OpGetBucketEncryption:        {method: http.MethodGet, subresource: "encryption"},
+	OpGetBucketLifecycle:         {method: http.MethodGet, subresource: "lifecycle"},
+	OpGetBucketTagging:           {method: http.MethodGet, subresource: "tagging"},
+	OpListObjectsV2:              {method: http.MethodGet, query: map[string]string{"list-type": "2"}},
+	OpListObjectVersions:         {method: http.MethodGet, subresource: "versions"},
+	OpHeadObject:                 {method: http.MethodHead},
+	OpPutObject:                  {method: http.MethodPut},
+	OpDeleteObject:               {method: http.MethodDelete},
+
+	OpGetCallerIdentity: {
+		method:  http.MethodGet,
+		query:   map[string]string{"Action": "GetCallerIdentity", "Version": "2011-06-15"},
+		service: serviceSTS,
+	},
+	OpGetAccountPublicAccessBlock: {
+		method:  http.MethodGet,
+		service: serviceS3Control,
+		path:    "/v20180820/configuration/publicAccessBlock",
+	},
+}
+
+// Request is a single S3 API call
+type Request struct {
+	Op     Op
+	Bucket string
+	Key    string
+	// Query holds additional query parameters (e.g. list-type=2)
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+	// Anonymous sends the request unsigned
+	Anonymous bool
+	// Region overrides the client's default region
+	Region string
+	// AccountID is the account S3 Control operations act on
+	AccountID string
+	// ExpectedBucketOwner makes S3 refuse the request (403) unless this
+	// account owns the bucket
+	ExpectedBucketOwner string
+}
+
+// Response is the raw result of an S3 API call. Non-2xx responses are not
+// treated as errors; the caller inspects StatusCode and Body.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// OK reports whether the response has a 2xx status code
+func (r *Response) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+type Client struct {
+	httpClient  *http.Client
+	credentials *credentialProvider
+	region      string
+}
+
+// NewClient returns a client for the default region. Credentials are only
+// resolved when the first signed request is made. Requests are bounded by
+// their context alone, so the caller's timeout (or none) is what applies.
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{
+			// S3 redirects point at other regions and would need re-signing
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		credentials: &credentialProvider{},
+		region:      LoadRegion(),
+	}
+}
+
+// Region returns the client's default region
+func (c *Client) Region() string {
+	return c.region
+}
+
+// Do sends req and returns the response. An error is only returned when no
+// response could be obtained (bad request, missing credentials, network failure).
+func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	spec, ok := operations[req.Op]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported operation %q", ErrInvalidRequest, req.Op)
+	}
+
+	region := req.Region
+	if region == "" {
+		region = c.region
+	}
+
+	query := url.Values{}
+	for k, v := range req.Query {
+		query[k] = v
+	}
+	if spec.subresource != "" {
+		query.Set(spec.subresource, "")
+	}
+	for k, v := range spec.query {
+		query.Set(k, v)
+	}
+
+	u, signingName := endpoint(region, req.Bucket, req.Key), "s3"
+	switch spec.service {
+	case serviceSTS:
+		u, signingName = serviceEndpoint("sts."+region+".amazonaws.com", "/"), "sts"
+	case serviceS3Control:
+		if req.AccountID == "" {
+			return nil, fmt.Errorf("%w: %s needs an account ID", ErrInvalidRequest, req.Op)
+		}
+		u = serviceEndpoint(req.AccountID+".s3-control."+region+".amazonaws.com", spec.path)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	var body io.Reader
+	if req.Body != nil {
+		body = bytes.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, spec.method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	for k, v := range req.Header {
+		httpReq.Header[k] = v
+	}
+	if req.AccountID != "" && spec.service == serviceS3Control {
+		httpReq.Header.Set("X-Amz-Account-Id", req.AccountID)
+	}
+	if req.ExpectedBucketOwner != "" {
+		httpReq.Header.Set("X-Amz-Expected-Bucket-Owner", req.ExpectedBucketOwner)
+	}
+	if req.Body != nil {
+		sum := md5.Sum(req.Body)
+		httpReq.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	if !req.Anonymous {
+		creds, err := c.credentials.Retrieve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		payloadHash := emptyPayloadHash
+		if req.Body != nil {
+			payloadHash = hashHex(req.Body)
+		}
+		signV4(httpReq, payloadHash, creds, region, signingName, time.Now())
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		StatusCode: httpResp.StatusCode,
+		Header:     httpResp.Header,
+		Body:       respBody,
+	}, nil
+}
+
+// endpoint builds the request URL. Virtual-hosted style is used where the
+// bucket name allows it; names containing dots would break TLS hostname
+// validation, so those fall back to path style.
+func endpoint(region, bucket, key string) *url.URL {
+	host := "s3." + region + ".amazonaws.com"
+	path := "/"
+
+	switch {
+	case bucket == "":
+	case strings.Contains(bucket, ".") || strings.ToLower(bucket) != bucket:
+		path = "/" + bucket + "/"
+	default:
+		host = bucket + "." + host
+	}
+	path += key
+
+	u := &url.URL{Scheme: "https", Host: host, Path: path}
+	u.RawPath = canonicalURI(u)
+	return u
+}
+
+// serviceEndpoint builds the URL of a request to STS or S3 Control
+func serviceEndpoint(host, path string) *url.URL {
+	u := &url.URL{Scheme: "https", Host: host, Path: path}
+	u.RawPath = canonicalURI(u)
+	return u
+}
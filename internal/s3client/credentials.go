@@ -0,0 +1,357 @@
+package s3client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRegion = "us-east-1"
+
+	// EC2 instance metadata service (IMDSv2) endpoints used for IAM role credentials
+	imdsTokenURL = "http://169.254.169.254/latest/api/token"
+	imdsRoleURL  = "http://169.254.169.254/latest/meta-data/iam/security-credentials/"
+	imdsTimeout  = time.Second
+
+	// ECS task role credentials are served here under
+	// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI
+	containerCredentialsHost = "http://169.254.170.2"
+	containerTimeout         = 2 * time.Second
+)
+
+// ErrNoCredentials is returned when no AWS credentials could be found in the
+// environment, the shared credentials and config files, the container
+// credentials endpoint or the instance metadata service. The error returned
+// wraps it together with the reason.
+var ErrNoCredentials = errors.New("no AWS credentials found")
+
+// unsupportedProfileKeys mark profiles whose credentials come from somewhere
+// the native client cannot reach; the AWS CLI transport handles them
+var unsupportedProfileKeys = []string{"sso_session", "sso_start_url", "role_arn", "credential_process", "web_identity_token_file"}
+
+// Credentials holds the AWS access key used to sign requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Expires is zero for static credentials
+	Expires time.Time
+}
+
+func (c Credentials) expired() bool {
+	return !c.Expires.IsZero() && time.Now().Add(time.Minute).After(c.Expires)
+}
+
+// credentialProvider resolves credentials lazily and caches them until they expire
+type credentialProvider struct {
+	mu    sync.Mutex
+	creds Credentials
+	err   error
+	done  bool
+}
+
+func (p *credentialProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done && !p.creds.expired() {
+		return p.creds, p.err
+	}
+	p.creds, p.err = loadCredentials(ctx)
+	p.done = true
+	return p.creds, p.err
+}
+
+// loadCredentials follows the usual AWS lookup order: environment variables,
+// the profile in the shared credentials and config files, container
+// credentials (ECS, EKS Pod Identity), then the EC2 instance role
+func loadCredentials(ctx context.Context) (Credentials, error) {
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		secret := os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if secret == "" {
+			return Credentials{}, fmt.Errorf("%w: AWS_ACCESS_KEY_ID is set but AWS_SECRET_ACCESS_KEY is not", ErrNoCredentials)
+		}
+		return Credentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	creds, found, err := loadProfileCredentials()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%w: %w", ErrNoCredentials, err)
+	}
+	if found {
+		return creds, nil
+	}
+
+	if containerCredentialsURI() != "" {
+		creds, err := loadContainerCredentials(ctx)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("%w: container credentials: %w", ErrNoCredentials, err)
+		}
+		return creds, nil
+	}
+
+	creds, err = loadInstanceRoleCredentials(ctx)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%w: instance role: %w", ErrNoCredentials, err)
+	}
+	return creds, nil
+}
+
+// loadProfileCredentials reads static keys of the profile from the shared
+// credentials file, then the config file. found is false when neither has
+// keys for the profile; profiles that get credentials in a way the native
+// client does not support are an error.
+func loadProfileCredentials() (Credentials, bool, error) {
+	profile := profileName()
+	for _, source := range []struct{ path, section string }{
+		{sharedCredentialsFile(), profile},
+		{sharedConfigFile(), configSection(profile)},
+	} {
+		section, err := readIniSection(source.path, source.section)
+		if err != nil {
+			continue
+		}
+		if section["aws_access_key_id"] != "" {
+			if section["aws_secret_access_key"] == "" {
+				return Credentials{}, false, fmt.Errorf("profile %q in %s has no aws_secret_access_key", profile, source.path)
+			}
+			return Credentials{
+				AccessKeyID:     section["aws_access_key_id"],
+				SecretAccessKey: section["aws_secret_access_key"],
+				SessionToken:    section["aws_session_token"],
+			}, true, nil
+		}
+		for _, key := range unsupportedProfileKeys {
+			if section[key] != "" {
+				return Credentials{}, false, fmt.Errorf("profile %q uses %s, which the built-in client does not support (use --transport cli)", profile, key)
+			}
+		}
+	}
+	return Credentials{}, false, nil
+}
+
+// LoadRegion returns the default region from the environment or the shared
+// config file, falling back to us-east-1
+func LoadRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	if region := os.Getenv("AWS_DEFAULT_REGION"); region != "" {
+		return region
+	}
+
+	section, err := readIniSection(sharedConfigFile(), configSection(profileName()))
+	if err == nil && section["region"] != "" {
+		return section["region"]
+	}
+	return defaultRegion
+}
+
+func profileName() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+// configSection names a profile's section in the config file, which prefixes
+// every profile except default with "profile "
+func configSection(profile string) string {
+	if profile == "default" {
+		return profile
+	}
+	return "profile " + profile
+}
+
+func sharedCredentialsFile() string {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "credentials")
+}
+
+func sharedConfigFile() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "config")
+}
+
+// readIniSection returns the key/value pairs of a single section of an AWS ini file
+func readIniSection(path, name string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == name
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values, scanner.Err()
+}
+
+// loadInstanceRoleCredentials fetches temporary credentials for the EC2 instance role using IMDSv2
+func loadInstanceRoleCredentials(ctx context.Context) (Credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, imdsTimeout)
+	defer cancel()
+
+	token, err := metadataGet(ctx, http.MethodPut, imdsTokenURL, map[string]string{
+		"X-aws-ec2-metadata-token-ttl-seconds": "21600",
+	})
+	if err != nil {
+		return Credentials{}, err
+	}
+	tokenHeader := map[string]string{"X-aws-ec2-metadata-token": token}
+
+	role, err := metadataGet(ctx, http.MethodGet, imdsRoleURL, tokenHeader)
+	if err != nil {
+		return Credentials{}, err
+	}
+	role = strings.TrimSpace(strings.SplitN(role, "\n", 2)[0])
+
+	body, err := metadataGet(ctx, http.MethodGet, imdsRoleURL+role, tokenHeader)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return parseCredentialDocument(body, "instance role")
+}
+
+// containerCredentialsURI is the endpoint ECS and EKS Pod Identity announce
+// in the environment, or empty when not running in such a container
+func containerCredentialsURI() string {
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		return containerCredentialsHost + relative
+	}
+	return os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+}
+
+// loadContainerCredentials fetches the task or pod role credentials from the
+// container credentials endpoint
+func loadContainerCredentials(ctx context.Context) (Credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, containerTimeout)
+	defer cancel()
+
+	uri := containerCredentialsURI()
+	if err := checkContainerURI(uri); err != nil {
+		return Credentials{}, err
+	}
+	headers := map[string]string{}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if path := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Credentials{}, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		headers["Authorization"] = token
+	}
+
+	body, err := metadataGet(ctx, http.MethodGet, uri, headers)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return parseCredentialDocument(body, "container credentials")
+}
+
+// checkContainerURI only lets credentials come over HTTPS or from a local or
+// link-local endpoint, as the AWS SDKs do
+func checkContainerURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "https" {
+		return nil
+	}
+	ip := net.ParseIP(u.Hostname())
+	if u.Scheme == "http" && (u.Hostname() == "localhost" || ip != nil && (ip.IsLoopback() || ip.IsLinkLocalUnicast())) {
+		return nil
+	}
+	return fmt.Errorf("refusing credentials endpoint %s: not HTTPS, loopback or link-local", uri)
+}
+
+// parseCredentialDocument reads the JSON credentials served by the instance
+// metadata service and the container credentials endpoint
+func parseCredentialDocument(body, source string) (Credentials, error) {
+	var doc struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+		Expiration      time.Time
+	}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return Credentials{}, fmt.Errorf("parsing %s: %w", source, err)
+	}
+	if doc.AccessKeyID == "" || doc.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%s: no access key in response", source)
+	}
+	return Credentials{
+		AccessKeyID:     doc.AccessKeyID,
+		SecretAccessKey: doc.SecretAccessKey,
+		SessionToken:    doc.Token,
+		Expires:         doc.Expiration,
+	}, nil
+}
+
+// metadataGet reads a credentials endpoint (instance metadata or container)
+func metadataGet(ctx context.Context, method, url string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return string(body), nil
+}
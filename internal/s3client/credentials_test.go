@@ -0,0 +1,161 @@
+package s3client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolateCredentials clears every credential source so each test sets only its own
+func isolateCredentials(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvironmentCredentials(t *testing.T) {
+	isolateCredentials(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	creds, err := loadCredentials(context.Background())
+	if err != nil || creds.AccessKeyID != "AKID" || creds.SecretAccessKey != "secret" {
+		t.Errorf("loadCredentials = %+v, %v", creds, err)
+	}
+}
+
+func TestEnvironmentKeyWithoutSecret(t *testing.T) {
+	isolateCredentials(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+
+	_, err := loadCredentials(context.Background())
+	if !errors.Is(err, ErrNoCredentials) || !strings.Contains(err.Error(), "AWS_SECRET_ACCESS_KEY") {
+		t.Errorf("err = %v, want ErrNoCredentials naming AWS_SECRET_ACCESS_KEY", err)
+	}
+}
+
+func TestProfileCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials string
+		config      string
+		wantKey     string
+		wantErr     string
+	}{
+		{
+			name:        "credentials file",
+			credentials: "[dev]\naws_access_key_id = AKCRED\naws_secret_access_key = s\n",
+			wantKey:     "AKCRED",
+		},
+		{
+			name:    "config file",
+			config:  "[profile dev]\nregion = eu-west-1\naws_access_key_id = AKCONF\naws_secret_access_key = s\n",
+			wantKey: "AKCONF",
+		},
+		{
+			name:    "SSO profile",
+			config:  "[profile dev]\nsso_session = corp\nsso_account_id = 111122223333\n",
+			wantErr: "--transport cli",
+		},
+		{
+			name:    "assumed role profile",
+			config:  "[profile dev]\nrole_arn = arn:aws:iam::111122223333:role/r\nsource_profile = default\n",
+			wantErr: "role_arn",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolateCredentials(t)
+			t.Setenv("AWS_PROFILE", "dev")
+			writeFile(t, filepath.Join(dir, "credentials"), tt.credentials)
+			writeFile(t, filepath.Join(dir, "config"), tt.config)
+
+			creds, found, err := loadProfileCredentials()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want one mentioning %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !found || creds.AccessKeyID != tt.wantKey {
+				t.Errorf("loadProfileCredentials = %+v, %v, %v; want key %s", creds, found, err, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestContainerCredentials(t *testing.T) {
+	dir := isolateCredentials(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "pod-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"AccessKeyId":"AKCONTAINER","SecretAccessKey":"s","Token":"t","Expiration":"2030-01-01T00:00:00Z"}`)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(dir, "token")
+	writeFile(t, tokenFile, "pod-token\n")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/creds")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", tokenFile)
+
+	creds, err := loadCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "AKCONTAINER" || creds.SessionToken != "t" || creds.Expires.Year() != 2030 {
+		t.Errorf("creds = %+v", creds)
+	}
+}
+
+func TestContainerCredentialsError(t *testing.T) {
+	isolateCredentials(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL)
+
+	_, err := loadCredentials(context.Background())
+	if !errors.Is(err, ErrNoCredentials) || !strings.Contains(err.Error(), "401") {
+		t.Errorf("err = %v, want ErrNoCredentials with the endpoint's answer", err)
+	}
+}
+
+func TestContainerURIMustBeLocalOrHTTPS(t *testing.T) {
+	for uri, ok := range map[string]bool{
+		"http://169.254.170.2/v2/credentials/x": true,
+		"http://169.254.170.23/v1/credentials":  true,
+		"http://127.0.0.1:8080/creds":           true,
+		"http://localhost/creds":                true,
+		"https://creds.example.com/x":           true,
+		"http://creds.example.com/x":            false,
+	} {
+		if err := checkContainerURI(uri); (err == nil) != ok {
+			t.Errorf("checkContainerURI(%q) = %v, want ok=%v", uri, err, ok)
+		}
+	}
+}
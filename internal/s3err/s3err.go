@@ -0,0 +1,182 @@
+// Package s3err parses S3 error responses and sorts error codes into the
+// categories the checker reports on.
+package s3err
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"s3-check/internal/s3client"
+)
+
+// Category groups error codes by what they tell us about a permission
+type Category string
+
+const (
+	// Denied: the caller is authenticated (or anonymous) and was refused
+	Denied Category = "denied"
+	// BucketNotFound: the bucket does not exist
+	BucketNotFound Category = "bucket-not-found"
+	// Absent: the bucket exists but the object or configuration asked for does not
+	Absent Category = "absent"
+	// Redirect: the bucket lives in another region or endpoint
+	Redirect Category = "redirect"
+	// Credentials: our own credentials are missing, invalid or expired
+	Credentials Category = "credentials"
+	// Throttled: S3 asked us to slow down or timed out; the request can be retried
+	Throttled Category = "throttled"
+	// Other: anything not recognised
+	Other Category = "other"
+)
+
+var categories = map[string]Category{
+	"AccessDenied":                  Denied,
+	"AllAccessDisabled":             Denied,
+	"Forbidden":                     Denied,
+	"AccessControlListNotSupported": Denied,
+
+	"NoSuchBucket": BucketNotFound,
+
+	"NotFound":                                       Absent,
+	"NoSuchKey":                                      Absent,
+	"NoSuchVersion":                                  Absent,
+	"NoSuchBucketPolicy":                             Absent,
+	"NoSuchPublicAccessBlockConfiguration":           Absent,
+	"NoSuchCORSConfiguration":                        Absent,
+	"NoSuchWebsiteConfiguration":                     Absent,
+	"NoSuchLifecycleConfiguration":                   Absent,
+	"NoSuchTagSet":                                   Absent,
+	"OwnershipControlsNotFoundError":                 Absent,
+	"ServerSideEncryptionConfigurationNotFoundError": Absent,
+
+	"PermanentRedirect":                  Redirect,
+	"TemporaryRedirect":                  Redirect,
+	"Redirect":                           Redirect,
+	"AuthorizationHeaderMalformed":       Redirect,
+	"IllegalLocationConstraintException": Redirect,
+
+	"InvalidAccessKeyId":    Credentials,
+	"SignatureDoesNotMatch": Credentials,
+	"ExpiredToken":          Credentials,
+	"InvalidToken":          Credentials,
+	"TokenRefreshRequired":  Credentials,
+	"AccountProblem":        Credentials,
+	"InvalidSecurity":       Credentials,
+	"RequestTimeTooSkewed":  Credentials,
+
+	"SlowDown":           Throttled,
+	"ServiceUnavailable": Throttled,
+	"RequestTimeout":     Throttled,
+	"InternalError":      Throttled,
+	"Throttling":         Throttled,
+}
+
+// Error is a parsed S3 error response
+type Error struct {
+	Code       string
+	Message    string
+	StatusCode int
+	RequestID  string
+	HostID     string
+	// Region is the bucket region reported by S3, if any
+	Region string
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s (%d): %s", e.Code, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s (%d)", e.Code, e.StatusCode)
+}
+
+// Category returns the category of the error's code
+func (e *Error) Category() Category {
+	return Classify(e.Code)
+}
+
+// Classify maps an S3 error code to its category
+func Classify(code string) Category {
+	if category, ok := categories[code]; ok {
+		return category
+	}
+	return Other
+}
+
+// Parse extracts the error from a non-2xx response. It returns nil for
+// successful responses. Bodies may be XML (the REST API) or JSON (control
+// plane endpoints); HEAD responses carry no body, so the code is derived
+// from the status.
+func Parse(resp *s3client.Response) *Error {
+	if resp == nil || resp.OK() {
+		return nil
+	}
+
+	var body struct {
+		Code      string `xml:"Code" json:"Code"`
+		Message   string `xml:"Message" json:"Message"`
+		RequestID string `xml:"RequestId" json:"RequestId"`
+		HostID    string `xml:"HostId" json:"HostId"`
+		Region    string `xml:"Region" json:"Region"`
+		// STS and S3 Control wrap the error in an ErrorResponse
+		Nested struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Error" json:"-"`
+	}
+	trimmed := bytes.TrimSpace(resp.Body)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		json.Unmarshal(trimmed, &body)
+	} else if len(trimmed) > 0 {
+		xml.Unmarshal(trimmed, &body)
+	}
+	if body.Code == "" {
+		body.Code, body.Message = body.Nested.Code, body.Nested.Message
+	}
+
+	e := &Error{
+		Code:       body.Code,
+		Message:    body.Message,
+		StatusCode: resp.StatusCode,
+		RequestID:  body.RequestID,
+		HostID:     body.HostID,
+		Region:     body.Region,
+	}
+	if resp.Header != nil {
+		if e.RequestID == "" {
+			e.RequestID = resp.Header.Get("X-Amz-Request-Id")
+		}
+		if e.HostID == "" {
+			e.HostID = resp.Header.Get("X-Amz-Id-2")
+		}
+		if region := resp.Header.Get("X-Amz-Bucket-Region"); region != "" {
+			e.Region = region
+		}
+	}
+	if e.Code == "" {
+		e.Code = codeForStatus(resp.StatusCode)
+	}
+	return e
+}
+
+// codeForStatus names bodiless error responses, as S3 SDKs do for HEAD requests
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusMovedPermanently:
+		return "PermanentRedirect"
+	case http.StatusTemporaryRedirect:
+		return "TemporaryRedirect"
+	case http.StatusForbidden:
+		return "Forbidden"
+	case http.StatusNotFound:
+		return "NotFound"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailable"
+	case http.StatusInternalServerError:
+		return "InternalError"
+	}
+	return strconv.Itoa(status)
+}
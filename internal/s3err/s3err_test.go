@@ -0,0 +1,114 @@
+package s3err
+
+import (
+	"net/http"
+	"testing"
+
+	"s3-check/internal/s3client"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   Error
+	}{
+		{
+			name:   "XML body",
+			status: http.StatusForbidden,
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessDenied</Code><Message>Access Denied</Message><RequestId>REQ1</RequestId><HostId>HOST1</HostId></Error>`,
+			want: Error{Code: "AccessDenied", Message: "Access Denied", StatusCode: 403, RequestID: "REQ1", HostID: "HOST1"},
+		},
+		{
+			name:   "XML redirect with region",
+			status: http.StatusMovedPermanently,
+			body:   `<Error><Code>PermanentRedirect</Code><Message>Use the specified endpoint</Message><Region>eu-west-1</Region></Error>`,
+			want:   Error{Code: "PermanentRedirect", Message: "Use the specified endpoint", StatusCode: 301, Region: "eu-west-1"},
+		},
+		{
+			name:   "JSON body",
+			status: http.StatusNotFound,
+			body:   ` {"Code":"NoSuchPublicAccessBlockConfiguration","Message":"not found","RequestId":"REQ2"}`,
+			want:   Error{Code: "NoSuchPublicAccessBlockConfiguration", Message: "not found", StatusCode: 404, RequestID: "REQ2"},
+		},
+		{
+			name:   "nested ErrorResponse",
+			status: http.StatusForbidden,
+			body:   `<ErrorResponse><Error><Type>Sender</Type><Code>ExpiredToken</Code><Message>token expired</Message></Error><RequestId>REQ3</RequestId></ErrorResponse>`,
+			want:   Error{Code: "ExpiredToken", Message: "token expired", StatusCode: 403, RequestID: "REQ3"},
+		},
+		{
+			name:   "HEAD 301",
+			status: http.StatusMovedPermanently,
+			header: http.Header{"X-Amz-Bucket-Region": {"ap-southeast-2"}},
+			want:   Error{Code: "PermanentRedirect", StatusCode: 301, Region: "ap-southeast-2"},
+		},
+		{
+			name:   "HEAD 403",
+			status: http.StatusForbidden,
+			header: http.Header{"X-Amz-Request-Id": {"REQ4"}, "X-Amz-Id-2": {"HOST4"}},
+			want:   Error{Code: "Forbidden", StatusCode: 403, RequestID: "REQ4", HostID: "HOST4"},
+		},
+		{
+			name:   "HEAD 404",
+			status: http.StatusNotFound,
+			want:   Error{Code: "NotFound", StatusCode: 404},
+		},
+		{
+			name:   "body fields win over headers, bucket region header over body",
+			status: http.StatusMovedPermanently,
+			header: http.Header{"X-Amz-Request-Id": {"HEADER"}, "X-Amz-Bucket-Region": {"us-west-2"}},
+			body:   `<Error><Code>PermanentRedirect</Code><RequestId>BODY</RequestId><Region>eu-west-1</Region></Error>`,
+			want:   Error{Code: "PermanentRedirect", StatusCode: 301, RequestID: "BODY", Region: "us-west-2"},
+		},
+		{
+			name:   "unknown status",
+			status: http.StatusTeapot,
+			want:   Error{Code: "418", StatusCode: 418},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(&s3client.Response{StatusCode: tt.status, Header: tt.header, Body: []byte(tt.body)})
+			if got == nil {
+				t.Fatal("Parse returned nil")
+			}
+			if *got != tt.want {
+				t.Errorf("Parse = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSuccess(t *testing.T) {
+	if e := Parse(&s3client.Response{StatusCode: http.StatusOK}); e != nil {
+		t.Errorf("Parse of 200 = %v, want nil", e)
+	}
+	if e := Parse(nil); e != nil {
+		t.Errorf("Parse(nil) = %v, want nil", e)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := map[string]Category{
+		"AccessDenied":          Denied,
+		"AllAccessDisabled":     Denied,
+		"NoSuchBucket":          BucketNotFound,
+		"NoSuchKey":             Absent,
+		"PermanentRedirect":     Redirect,
+		"ExpiredToken":          Credentials,
+		"SignatureDoesNotMatch": Credentials,
+		"SlowDown":              Throttled,
+		"ServiceUnavailable":    Throttled,
+		"SomethingNew":          Other,
+		"":                      Other,
+	}
+	for code, want := range tests {
+		if got := Classify(code); got != want {
+			t.Errorf("Classify(%q) = %s, want %s", code, got, want)
+		}
+	}
+}
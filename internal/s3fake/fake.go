@@ -0,0 +1,140 @@
+// Package s3fake provides an in-memory Transport with scripted per-bucket
+// responses, so checker logic can be exercised without talking to S3.
+package s3fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"s3-check/internal/s3client"
+)
+
+// Handler produces the response for a matched request
+type Handler func(req *s3client.Request) (*s3client.Response, error)
+
+type route struct {
+	bucket string
+	op     s3client.Op
+	// mode is "anon", "auth" or "" for both
+	mode string
+}
+
+// Fake is a scripted S3. Lookups go from the most to the least specific
+// route: bucket+operation+signing mode, bucket+operation, bucket, then Default.
+type Fake struct {
+	mu     sync.Mutex
+	routes map[route]Handler
+	calls  []s3client.Request
+
+	// Default answers requests that match no route (403 AccessDenied unless replaced)
+	Default Handler
+}
+
+func New() *Fake {
+	return &Fake{
+		routes:  make(map[route]Handler),
+		Default: Respond(Error(http.StatusForbidden, "AccessDenied")),
+	}
+}
+
+// Handle scripts op on bucket for both signed and anonymous requests.
+// An empty op matches every operation on the bucket.
+func (f *Fake) Handle(bucket string, op s3client.Op, h Handler) *Fake {
+	return f.set(route{bucket: bucket, op: op}, h)
+}
+
+// HandleAnonymous scripts op on bucket for unsigned requests only
+func (f *Fake) HandleAnonymous(bucket string, op s3client.Op, h Handler) *Fake {
+	return f.set(route{bucket: bucket, op: op, mode: "anon"}, h)
+}
+
+// HandleAuthenticated scripts op on bucket for signed requests only
+func (f *Fake) HandleAuthenticated(bucket string, op s3client.Op, h Handler) *Fake {
+	return f.set(route{bucket: bucket, op: op, mode: "auth"}, h)
+}
+
+func (f *Fake) set(r route, h Handler) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[r] = h
+	return f
+}
+
+// Do records req and answers it from the scripted routes
+func (f *Fake) Do(ctx context.Context, req *s3client.Request) (*s3client.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, *req)
+	mode := "auth"
+	if req.Anonymous {
+		mode = "anon"
+	}
+	h := f.Default
+	for _, r := range []route{
+		{bucket: req.Bucket, op: req.Op, mode: mode},
+		{bucket: req.Bucket, op: req.Op},
+		{bucket: req.Bucket},
+	} {
+		if routed, ok := f.routes[r]; ok {
+			h = routed
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	return h(req)
+}
+
+// Calls returns a copy of every request received so far
+func (f *Fake) Calls() []s3client.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]s3client.Request(nil), f.calls...)
+}
+
+// Respond returns a Handler that always answers with resp
+func Respond(resp *s3client.Response) Handler {
+	return func(*s3client.Request) (*s3client.Response, error) {
+		return resp, nil
+	}
+}
+
+// Fail returns a Handler that always fails with err, as a network error would
+func Fail(err error) Handler {
+	return func(*s3client.Request) (*s3client.Response, error) {
+		return nil, err
+	}
+}
+
+// OK is an empty 200 response, or one carrying body
+func OK(body string) *s3client.Response {
+	return &s3client.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: []byte(body)}
+}
+
+// Status is a bare response with no body, as returned for HEAD requests
+func Status(code int) *s3client.Response {
+	return &s3client.Response{StatusCode: code, Header: http.Header{}}
+}
+
+// Error is an S3 XML error response
+func Error(status int, code string) *s3client.Response {
+	body := fmt.Sprintf("<Error><Code>%s</Code><Message>%s</Message><RequestId>FAKE</RequestId><HostId>FAKE</HostId></Error>", code, code)
+	return &s3client.Response{StatusCode: status, Header: http.Header{}, Body: []byte(body)}
+}
+
+// NoSuchBucket is the response for a bucket that does not exist
+func NoSuchBucket() *s3client.Response {
+	return Error(http.StatusNotFound, "NoSuchBucket")
+}
+
+// Redirect is the PermanentRedirect returned when a bucket lives in another region
+func Redirect(region string) *s3client.Response {
+	resp := Error(http.StatusMovedPermanently, "PermanentRedirect")
+	resp.Header.Set("X-Amz-Bucket-Region", region)
+	return resp
+}